@@ -8,95 +8,243 @@ import (
     "time"
 )
 
-var (
-    _ctx      context.Context
-    _cancel   context.CancelFunc
-    _alerters []*Alerter
-    _once     sync.Once
-    _mu       sync.Mutex
-)
-
 var (
     _ErrFatal = fmt.Errorf("this is a fatal error")
 )
 
+// _notifyTimeout bounds the delivery of one alert, so a stuck backend does
+// not hold up the other alerters.
+const _notifyTimeout = 30 * time.Second
+
 type HandleFunc func(title string, num int, msg string)
 
+// Start runs the default manager, see Manager.Start.
 func Start(ctx context.Context) error {
-    initialized := true
-    _once.Do(func() {
-        initialized = false
-    })
-    if initialized {
-        return fmt.Errorf("alerter already started")
-    }
-    _ctx, _cancel = context.WithCancel(ctx)
-    ticker := time.NewTicker(5 * time.Second)
-    defer ticker.Stop()
-    for {
-        select {
-        case <-_ctx.Done():
-            return context.Canceled
-        case <-ticker.C:
-            _mu.Lock()
-            alts := _alerters
-            _mu.Unlock()
-            for _, a := range alts {
-                a.SendAlert()
-            }
-        }
-    }
+    return _default.Start(ctx)
 }
 
+// Stop stops the default manager, see Manager.Stop.
 func Stop() {
-    if f := _cancel; f != nil {
-        f()
-    }
+    _default.Stop()
 }
 
 type Alerter struct {
-    title string
-    err   error
-    total int
-    f     HandleFunc
-    mu    sync.Mutex
+    title   string
+    groups  map[string]*Group
+    order   []*Group
+    dropped int
+    total   int
+    n       Notifier
+    opts    options
+    last    time.Time
+    mu      sync.Mutex
+
+    // policy state, see policy.go
+    window    int       // errors recorded in the current window
+    streak    int       // consecutive windows with errors
+    sent      int       // alerts sent in the current incident
+    next      time.Time // back-off: no alert before next
+    firing    bool      // an alert was sent and not resolved yet
+    escalated bool      // the current incident was escalated
+
+    journal  *journal
+    replayed bool // pending errors were replayed from the journal
+}
+
+// New creates an alerter calling f and registers it with the default manager.
+func New(title string, f HandleFunc, opts ...Option) *Alerter {
+    return _default.New(title, f, opts...)
+}
+
+// NewWithNotifier creates an alerter delivering through n and registers it
+// with the default manager.
+func NewWithNotifier(title string, n Notifier, opts ...Option) *Alerter {
+    return _default.NewWithNotifier(title, n, opts...)
 }
 
-func New(title string, f HandleFunc) *Alerter {
-    alert := &Alerter{
-        title: title,
-        err:   _ErrFatal,
-        f:     f,
+func newAlerter(title string, n Notifier, opts ...Option) *Alerter {
+    o := defaultOptions()
+    for _, opt := range opts {
+        opt(&o)
+    }
+    if o.handler != nil {
+        if n == nil {
+            n = o.handler
+        } else {
+            n = Multi(n, o.handler)
+        }
+    }
+    a := &Alerter{
+        title:  title,
+        groups: make(map[string]*Group),
+        n:      n,
+        opts:   o,
+        last:   time.Now(),
     }
-    _mu.Lock()
-    defer _mu.Unlock()
-    _alerters = append(_alerters, alert)
-    return alert
+    if o.journal != "" {
+        a.journal = newJournal(o.journal)
+        a.replay()
+    }
+    return a
 }
 
+// Fatal records err, grouped by its Fingerprint, and returns it.
 func (a *Alerter) Fatal(err error) error {
+    return a.FatalWithKey(Fingerprint(err), err)
+}
+
+// FatalWithKey records err under a caller supplied grouping key and returns it.
+// A nil err is counted in Alert.Num but has no group.
+func (a *Alerter) FatalWithKey(key string, err error) error {
+    if err == nil {
+        a.mu.Lock()
+        a.total++
+        a.window++
+        a.mu.Unlock()
+        return nil
+    }
+    now := time.Now()
+    msg := err.Error()
     a.mu.Lock()
     defer a.mu.Unlock()
-    a.total++
-    if a.total < 10 {
-        a.err = errors.Join(a.err, err)
+    if a.journal != nil {
+        a.journal.append(key, msg, now)
     }
+    a.record(key, msg, now)
     return err
 }
 
-func (a *Alerter) SendAlert() {
+// record adds one error to the pending groups. a.mu must be held.
+func (a *Alerter) record(key, msg string, now time.Time) {
+    a.total++
+    a.window++
+    if g, ok := a.groups[key]; ok {
+        g.Count++
+        g.Last = now
+        return
+    }
+    if len(a.order) >= a.opts.samples {
+        a.dropped++
+        return
+    }
+    g := &Group{
+        Key:   key,
+        Err:   msg,
+        Count: 1,
+        First: now,
+        Last:  now,
+    }
+    a.groups[key] = g
+    a.order = append(a.order, g)
+}
+
+// Severity returns the severity alerts are reported with.
+func (a *Alerter) Severity() Severity {
+    return a.opts.severity
+}
+
+// SendAlert flushes the pending errors right away, bypassing back-off and
+// quiet hours.
+func (a *Alerter) SendAlert() error {
+    now := time.Now()
     a.mu.Lock()
+    a.last = now
+    alert, t := a.take(now)
+    escalate := alert != nil && a.escalating()
+    a.escalated = a.escalated || escalate
+    a.mu.Unlock()
+
+    err := a.notify(alert, escalate)
+    a.settle(t, err)
+    return err
+}
+
+// taken is what take removed from the pending errors.
+type taken struct {
+    order        []*Group
+    num, dropped int
+    mark         int64 // journal size, the records sent with the alert
+}
+
+// take resets the pending errors and returns them as an alert,
+// or nil if there is nothing pending. a.mu must be held.
+func (a *Alerter) take(now time.Time) (*Alert, *taken) {
     if a.total == 0 {
-        a.mu.Unlock()
-        return
+        return nil, nil
     }
     num := a.total
-    err := a.err
-    a.err = _ErrFatal
+    order, dropped := a.order, a.dropped
+    t := &taken{order: order, num: num, dropped: dropped}
+    if a.journal != nil {
+        t.mark = a.journal.size()
+    }
+    a.groups = make(map[string]*Group)
+    a.order = nil
+    a.dropped = 0
     a.total = 0
-    a.mu.Unlock()
+    a.sent++
+    a.next = now.Add(a.opts.backoff(a.sent))
+    a.firing = true
+
+    groups := make([]Group, 0, len(order))
+    for _, g := range order {
+        groups = append(groups, *g)
+    }
+    return &Alert{
+        Title:    a.title,
+        Severity: a.opts.severity,
+        Num:      num,
+        Msg:      formatGroups(order, dropped),
+        Groups:   groups,
+        Time:     now,
+    }, t
+}
 
-    if a.f != nil {
-        a.f(a.title, num, err.Error())
+// settle drops the journal records of a delivered alert. If delivery
+// failed, the errors are pending again and stay in the journal.
+func (a *Alerter) settle(t *taken, err error) {
+    if t == nil {
+        return
+    }
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    if err == nil {
+        if a.journal != nil {
+            a.journal.drop(t.mark)
+        }
+        return
+    }
+    order := make([]*Group, 0, len(t.order)+len(a.order))
+    for _, g := range t.order {
+        if cur, ok := a.groups[g.Key]; ok {
+            cur.Count += g.Count
+            cur.First = g.First
+            continue
+        }
+        a.groups[g.Key] = g
+        order = append(order, g)
+    }
+    a.order = append(order, a.order...)
+    a.total += t.num
+    a.dropped += t.dropped
+}
+
+func (a *Alerter) notify(alert *Alert, escalate bool) error {
+    if alert == nil {
+        return nil
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), _notifyTimeout)
+    defer cancel()
+    var errs []error
+    if a.n != nil {
+        if err := a.n.Notify(ctx, alert); err != nil {
+            errs = append(errs, err)
+        }
+    }
+    if escalate {
+        if err := a.opts.escalation.Notify(ctx, alert); err != nil {
+            errs = append(errs, err)
+        }
     }
+    return errors.Join(errs...)
 }
@@ -2,7 +2,9 @@ package alerter_test
 
 import (
     "context"
+    "errors"
     "fmt"
+    "strings"
     "testing"
     "time"
 
@@ -39,3 +41,135 @@ func Test_Alert(t *testing.T) {
         }
     }
 }
+
+func Test_AlertStartStop(t *testing.T) {
+    m := alerter.NewManager()
+    rec := &recorder{}
+    alt := m.NewWithNotifier("start stop", rec, alerter.WithInterval(time.Hour))
+
+    // a canceled context stops without flushing
+    ctx, cancel := context.WithCancel(context.Background())
+    errc := make(chan error, 1)
+    go func() { errc <- m.Start(ctx) }()
+    time.Sleep(50 * time.Millisecond)
+    alt.Fatal(errors.New("pending"))
+    cancel()
+    if err := <-errc; !errors.Is(err, context.Canceled) {
+        t.Errorf("unexpected error: %v", err)
+    }
+    if rec.len() != 0 {
+        t.Errorf("flushed on cancel, got %d alerts", rec.len())
+    }
+
+    // Stop flushes a last time
+    go func() { errc <- m.Start(context.Background()) }()
+    time.Sleep(50 * time.Millisecond)
+    m.Stop()
+    <-errc
+    if rec.len() != 1 {
+        t.Errorf("Stop did not flush, got %d alerts", rec.len())
+    }
+    m.Stop()
+}
+
+func Test_AlertOptions(t *testing.T) {
+    var got *alerter.Alert
+    n := alerter.NotifierFunc(func(_ context.Context, a *alerter.Alert) error {
+        got = a
+        return nil
+    })
+    alt := alerter.NewWithNotifier("opts", n,
+        alerter.WithInterval(time.Minute),
+        alerter.WithSamples(2),
+        alerter.WithSeverity(alerter.SeverityCritical),
+    )
+    alt.Fatal(fmt.Errorf("opts error a"))
+    alt.Fatal(fmt.Errorf("opts error b"))
+    alt.Fatal(fmt.Errorf("opts error c"))
+    alt.Fatal(fmt.Errorf("opts error a"))
+    alt.Fatal(fmt.Errorf("opts error d"))
+    if err := alt.SendAlert(); err != nil {
+        t.Fatal(err)
+    }
+    if got == nil {
+        t.Fatal("no alert sent")
+    }
+    if got.Num != 5 || got.Severity != alerter.SeverityCritical {
+        t.Errorf("unexpected alert: %+v", got)
+    }
+    if len(got.Groups) != 2 || strings.Contains(got.Msg, "opts error c") || !strings.Contains(got.Msg, "2 more occurrences") {
+        t.Errorf("unexpected samples: %q", got.Msg)
+    }
+}
+
+func Test_AlertGroups(t *testing.T) {
+    var got *alerter.Alert
+    alt := alerter.NewWithNotifier("groups", alerter.NotifierFunc(func(_ context.Context, a *alerter.Alert) error {
+        got = a
+        return nil
+    }))
+    for i := 0; i < 9; i++ {
+        alt.Fatal(fmt.Errorf("dial 10.0.0.%d: timeout", i))
+    }
+    alt.Fatal(errors.New("disk full"))
+    alt.FatalWithKey("disk", fmt.Errorf("disk full on /dev/sda"))
+    alt.FatalWithKey("disk", fmt.Errorf("disk full on /dev/sdb"))
+    alt.SendAlert()
+    if got == nil || got.Num != 12 || len(got.Groups) != 3 {
+        t.Fatalf("unexpected alert: %+v", got)
+    }
+    if g := got.Groups[0]; g.Count != 9 || g.Err != "dial 10.0.0.0: timeout" || g.Last.Before(g.First) {
+        t.Errorf("unexpected group: %+v", g)
+    }
+    if g := got.Groups[2]; g.Key != "disk" || g.Count != 2 {
+        t.Errorf("unexpected group: %+v", g)
+    }
+    if !strings.Contains(got.Msg, "9 occurrences of dial 10.0.0.0: timeout") ||
+        !strings.Contains(got.Msg, "1 occurrence of disk full") {
+        t.Errorf("unexpected message: %q", got.Msg)
+    }
+}
+
+func Test_AlertHandlerFunc(t *testing.T) {
+    var num int
+    var got *alerter.Alert
+    alt := alerter.New("handler", func(_ string, n int, _ string) {
+        num = n
+    }, alerter.WithSeverity(alerter.SeverityWarn), alerter.WithHandlerFunc(func(a *alerter.Alert) {
+        got = a
+    }))
+    alt.Fatal(errors.New("handler error"))
+    alt.SendAlert()
+    if num != 1 {
+        t.Errorf("HandleFunc not called, num %d", num)
+    }
+    if got == nil || got.Num != 1 || got.Severity != alerter.SeverityWarn {
+        t.Fatalf("unexpected alert: %+v", got)
+    }
+
+    got = nil
+    alt = alerter.New("handler only", nil, alerter.WithHandlerFunc(func(a *alerter.Alert) {
+        got = a
+    }))
+    alt.Fatal(errors.New("handler error"))
+    alt.SendAlert()
+    if got == nil || got.Severity != alerter.SeverityError {
+        t.Fatalf("unexpected alert: %+v", got)
+    }
+}
+
+func Test_AlertNil(t *testing.T) {
+    var got *alerter.Alert
+    alt := alerter.NewWithNotifier("nil", alerter.NotifierFunc(func(_ context.Context, a *alerter.Alert) error {
+        got = a
+        return nil
+    }))
+    if err := alt.Fatal(nil); err != nil {
+        t.Fatal(err)
+    }
+    alt.Fatal(errors.New("not nil"))
+    alt.SendAlert()
+    if got == nil || got.Num != 2 || len(got.Groups) != 1 {
+        t.Fatalf("unexpected alert: %+v", got)
+    }
+}
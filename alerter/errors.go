@@ -1 +1,14 @@
 package alerter
+
+import (
+    "fmt"
+)
+
+// WebhookError is returned when a webhook answers with a non-2xx status.
+type WebhookError struct {
+    StatusCode int
+}
+
+func (e *WebhookError) Error() string {
+    return fmt.Sprintf("alerter: webhook returned status %d", e.StatusCode)
+}
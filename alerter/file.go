@@ -0,0 +1,46 @@
+package alerter
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "strings"
+    "sync"
+)
+
+// FileNotifier appends alerts to a file, one alert per line.
+type FileNotifier struct {
+    path string
+    mu   sync.Mutex
+}
+
+// NewFile creates a notifier appending to path. The file is opened
+// for each alert so that it can be rotated externally.
+func NewFile(path string) *FileNotifier {
+    return &FileNotifier{
+        path: path,
+    }
+}
+
+// Notify implements Notifier.
+func (f *FileNotifier) Notify(_ context.Context, alert *Alert) error {
+    line := fmt.Sprintf("%s %s [%s] %d: %s\n",
+        alert.Time.Format("2006-01-02 15:04:05"),
+        alert.Severity,
+        alert.Title,
+        alert.Num,
+        strings.ReplaceAll(alert.Msg, "\n", "; "),
+    )
+
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    ff, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+    if err != nil {
+        return err
+    }
+    if _, err = ff.WriteString(line); err != nil {
+        ff.Close()
+        return err
+    }
+    return ff.Close()
+}
@@ -0,0 +1,56 @@
+package alerter
+
+import (
+    "fmt"
+    "regexp"
+    "strings"
+    "time"
+)
+
+var (
+    _digits = regexp.MustCompile(`[0-9]+`)
+)
+
+// Group is a set of errors sharing a fingerprint within one alert window.
+type Group struct {
+    Key   string    `json:"key"`
+    Err   string    `json:"err"`
+    Count int       `json:"count"`
+    First time.Time `json:"first"`
+    Last  time.Time `json:"last"`
+}
+
+// Fingerprint returns the default grouping key of err: its type plus its
+// message with all numbers stripped, so "conn 12 reset" and "conn 13 reset"
+// fall into the same group.
+func Fingerprint(err error) string {
+    if err == nil {
+        return ""
+    }
+    return fmt.Sprintf("%T:%s", err, _digits.ReplaceAllString(err.Error(), "#"))
+}
+
+func (g *Group) String() string {
+    occ := "occurrences"
+    if g.Count == 1 {
+        occ = "occurrence"
+    }
+    return fmt.Sprintf("%d %s of %s (first %s, last %s)",
+        g.Count, occ, g.Err,
+        g.First.Format("2006-01-02 15:04:05"),
+        g.Last.Format("2006-01-02 15:04:05"),
+    )
+}
+
+func formatGroups(groups []*Group, dropped int) string {
+    var sb strings.Builder
+    sb.WriteString(_ErrFatal.Error())
+    for _, g := range groups {
+        sb.WriteString("\n")
+        sb.WriteString(g.String())
+    }
+    if dropped > 0 {
+        fmt.Fprintf(&sb, "\n%d more occurrences of other errors", dropped)
+    }
+    return sb.String()
+}
@@ -0,0 +1,138 @@
+package alerter
+
+import (
+    "bufio"
+    "encoding/json"
+    "os"
+    "time"
+)
+
+// journal is an append-only file of errors not sent yet, one JSON
+// record per line.
+type journal struct {
+    path string
+    f    *os.File
+}
+
+type journalRecord struct {
+    Key  string    `json:"key"`
+    Err  string    `json:"err"`
+    Time time.Time `json:"time"`
+}
+
+func newJournal(path string) *journal {
+    return &journal{
+        path: path,
+    }
+}
+
+func (j *journal) append(key, msg string, t time.Time) error {
+    if j.f == nil {
+        f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+        if err != nil {
+            return err
+        }
+        j.f = f
+    }
+    b, err := json.Marshal(&journalRecord{Key: key, Err: msg, Time: t})
+    if err != nil {
+        return err
+    }
+    _, err = j.f.Write(append(b, '\n'))
+    return err
+}
+
+func (j *journal) size() int64 {
+    fi, err := os.Stat(j.path)
+    if err != nil {
+        return 0
+    }
+    return fi.Size()
+}
+
+// drop removes the first n bytes, the records of a delivered alert.
+// Records appended since are kept.
+func (j *journal) drop(n int64) error {
+    data, err := os.ReadFile(j.path)
+    if err != nil {
+        if os.IsNotExist(err) {
+            return nil
+        }
+        return err
+    }
+    rest := data[min(n, int64(len(data))):]
+    if j.f == nil {
+        return os.WriteFile(j.path, rest, 0644)
+    }
+    if err = j.f.Truncate(0); err != nil {
+        return err
+    }
+    _, err = j.f.Write(rest)
+    return err
+}
+
+func (j *journal) read() ([]journalRecord, error) {
+    f, err := os.Open(j.path)
+    if err != nil {
+        if os.IsNotExist(err) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    defer f.Close()
+
+    var recs []journalRecord
+    sc := bufio.NewScanner(f)
+    sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+    for sc.Scan() {
+        var rec journalRecord
+        // the last line may be torn by a crash, skip what cannot be decoded
+        if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+            continue
+        }
+        recs = append(recs, rec)
+    }
+    return recs, sc.Err()
+}
+
+func (j *journal) close() error {
+    if j.f == nil {
+        return nil
+    }
+    err := j.f.Close()
+    j.f = nil
+    return err
+}
+
+// replay loads the errors left in the journal by a previous process.
+func (a *Alerter) replay() {
+    recs, _ := a.journal.read()
+    if len(recs) == 0 {
+        return
+    }
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    for _, rec := range recs {
+        a.record(rec.Key, rec.Err, rec.Time)
+    }
+    a.replayed = true
+}
+
+func (a *Alerter) clearReplayed() bool {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    replayed := a.replayed
+    a.replayed = false
+    return replayed
+}
+
+// Close releases the journal file, if any. Pending errors stay in the
+// journal and are replayed by the next process.
+func (a *Alerter) Close() error {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    if a.journal == nil {
+        return nil
+    }
+    return a.journal.close()
+}
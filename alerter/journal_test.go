@@ -0,0 +1,20 @@
+package alerter
+
+import (
+    "errors"
+    "path/filepath"
+    "testing"
+)
+
+func Test_JournalClose(t *testing.T) {
+    m := NewManager()
+    a := m.NewWithNotifier("journal", &countNotifier{}, WithJournal(filepath.Join(t.TempDir(), "alert.journal")))
+    a.Fatal(errors.New("pending"))
+    if a.journal.f == nil {
+        t.Fatal("journal not opened")
+    }
+    m.Unregister(a)
+    if a.journal.f != nil {
+        t.Error("journal not closed on Unregister")
+    }
+}
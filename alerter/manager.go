@@ -0,0 +1,157 @@
+package alerter
+
+import (
+    "context"
+    "fmt"
+    "sync"
+    "time"
+)
+
+// _tick is the resolution alerters are checked for pending alerts with.
+const _tick = time.Second
+
+var (
+    _default = NewManager()
+)
+
+// Default returns the manager used by the package level functions.
+func Default() *Manager {
+    return _default
+}
+
+// Manager periodically flushes a set of alerters.
+type Manager struct {
+    alerters []*Alerter
+    cancel   context.CancelFunc
+    done     chan struct{}
+    mu       sync.Mutex
+}
+
+func NewManager() *Manager {
+    return &Manager{}
+}
+
+// New creates an alerter calling f and registers it with m.
+func (m *Manager) New(title string, f HandleFunc, opts ...Option) *Alerter {
+    var n Notifier
+    if f != nil {
+        n = f
+    }
+    return m.NewWithNotifier(title, n, opts...)
+}
+
+// NewWithNotifier creates an alerter delivering through n and registers it with m.
+func (m *Manager) NewWithNotifier(title string, n Notifier, opts ...Option) *Alerter {
+    a := newAlerter(title, n, opts...)
+    m.Register(a)
+    return a
+}
+
+// Register adds a to the alerters flushed by m.
+func (m *Manager) Register(a *Alerter) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for _, v := range m.alerters {
+        if v == a {
+            return
+        }
+    }
+    alts := make([]*Alerter, 0, len(m.alerters)+1)
+    alts = append(alts, m.alerters...)
+    m.alerters = append(alts, a)
+}
+
+// Unregister removes a from m, flushes its pending errors and closes its
+// journal.
+func (m *Manager) Unregister(a *Alerter) {
+    m.mu.Lock()
+    alts := make([]*Alerter, 0, len(m.alerters))
+    found := false
+    for _, v := range m.alerters {
+        if v == a {
+            found = true
+            continue
+        }
+        alts = append(alts, v)
+    }
+    m.alerters = alts
+    m.mu.Unlock()
+    if found {
+        a.SendAlert()
+        a.Close()
+    }
+}
+
+// Start sends the errors replayed from journals, then flushes the registered
+// alerters until ctx is done or Stop is called and returns context.Canceled.
+// Journals are closed on return. A stopped manager can be started again.
+func (m *Manager) Start(ctx context.Context) error {
+    m.mu.Lock()
+    if m.done != nil {
+        m.mu.Unlock()
+        return fmt.Errorf("alerter already started")
+    }
+    ctx, cancel := context.WithCancel(ctx)
+    done := make(chan struct{})
+    m.cancel, m.done = cancel, done
+    m.mu.Unlock()
+
+    defer func() {
+        // journals are reopened on the next error
+        for _, a := range m.snapshot() {
+            a.Close()
+        }
+        m.mu.Lock()
+        m.cancel, m.done = nil, nil
+        m.mu.Unlock()
+        cancel()
+        close(done)
+    }()
+
+    // errors replayed from a journal are sent right away
+    for _, a := range m.snapshot() {
+        if a.clearReplayed() {
+            a.SendAlert()
+        }
+    }
+
+    ticker := time.NewTicker(_tick)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return context.Canceled
+        case now := <-ticker.C:
+            for _, a := range m.snapshot() {
+                a.tick(now)
+            }
+        }
+    }
+}
+
+// Stop stops a running Start, waits for it to return and flushes the
+// registered alerters a last time.
+func (m *Manager) Stop() {
+    m.mu.Lock()
+    cancel, done := m.cancel, m.done
+    m.mu.Unlock()
+    if cancel == nil {
+        return
+    }
+    cancel()
+    <-done
+    m.Flush()
+}
+
+// Flush sends the pending alerts of every registered alerter.
+func (m *Manager) Flush() {
+    for _, a := range m.snapshot() {
+        a.SendAlert()
+    }
+}
+
+func (m *Manager) snapshot() []*Alerter {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    return m.alerters
+}
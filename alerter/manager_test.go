@@ -0,0 +1,132 @@
+package alerter_test
+
+import (
+    "context"
+    "errors"
+    "os"
+    "path/filepath"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/kakami/pkg/alerter"
+)
+
+type recorder struct {
+    alerts []*alerter.Alert
+    mu     sync.Mutex
+}
+
+func (r *recorder) Notify(_ context.Context, a *alerter.Alert) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.alerts = append(r.alerts, a)
+    return nil
+}
+
+func (r *recorder) len() int {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    return len(r.alerts)
+}
+
+func Test_ManagerRestart(t *testing.T) {
+    m := alerter.NewManager()
+    rec := &recorder{}
+    alt := m.NewWithNotifier("manager", rec, alerter.WithInterval(time.Hour))
+
+    for i := 0; i < 2; i++ {
+        errc := make(chan error, 1)
+        go func() { errc <- m.Start(context.Background()) }()
+        time.Sleep(50 * time.Millisecond)
+        if err := m.Start(context.Background()); err == nil {
+            t.Error("expected already started error")
+        }
+        alt.Fatal(errors.New("pending"))
+        m.Stop()
+        if err := <-errc; !errors.Is(err, context.Canceled) {
+            t.Errorf("unexpected error: %v", err)
+        }
+        if rec.len() != i+1 {
+            t.Errorf("final flush missing, got %d alerts", rec.len())
+        }
+    }
+}
+
+func Test_ManagerUnregister(t *testing.T) {
+    m := alerter.NewManager()
+    rec := &recorder{}
+    alt := m.NewWithNotifier("unregister", rec)
+    alt.Fatal(errors.New("pending"))
+    m.Unregister(alt)
+    if rec.len() != 1 {
+        t.Fatalf("unregister did not flush, got %d alerts", rec.len())
+    }
+    alt.Fatal(errors.New("pending"))
+    m.Flush()
+    if rec.len() != 1 {
+        t.Errorf("unregistered alerter flushed, got %d alerts", rec.len())
+    }
+    m.Register(alt)
+    m.Flush()
+    if rec.len() != 2 {
+        t.Errorf("registered alerter not flushed, got %d alerts", rec.len())
+    }
+}
+
+func Test_ManagerJournal(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "alert.journal")
+    crashed := alerter.NewManager().NewWithNotifier("journal", &recorder{}, alerter.WithJournal(path))
+    crashed.Fatal(errors.New("before crash 1"))
+    crashed.Fatal(errors.New("before crash 2"))
+    crashed.Fatal(errors.New("other"))
+    crashed.Close()
+
+    m := alerter.NewManager()
+    rec := &recorder{}
+    m.NewWithNotifier("journal", rec, alerter.WithJournal(path))
+    errc := make(chan error, 1)
+    go func() { errc <- m.Start(context.Background()) }()
+    time.Sleep(50 * time.Millisecond)
+    m.Stop()
+    <-errc
+
+    if rec.len() != 1 {
+        t.Fatalf("journal not replayed, got %d alerts", rec.len())
+    }
+    if a := rec.alerts[0]; a.Num != 3 || len(a.Groups) != 2 || a.Groups[0].Count != 2 {
+        t.Errorf("unexpected alert: %+v", a)
+    }
+    if fi, err := os.Stat(path); err != nil || fi.Size() != 0 {
+        t.Errorf("journal not reset after sending: %v", err)
+    }
+}
+
+func Test_ManagerJournalFailedNotify(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "alert.journal")
+    failing := alerter.NotifierFunc(func(context.Context, *alerter.Alert) error {
+        return errors.New("relay down")
+    })
+    crashed := alerter.NewManager().NewWithNotifier("journal", failing, alerter.WithJournal(path))
+    crashed.Fatal(errors.New("crash loop"))
+    if err := crashed.SendAlert(); err == nil {
+        t.Fatal("expected notify error")
+    }
+    crashed.Close()
+
+    m := alerter.NewManager()
+    rec := &recorder{}
+    m.NewWithNotifier("journal", rec, alerter.WithJournal(path))
+    errc := make(chan error, 1)
+    go func() { errc <- m.Start(context.Background()) }()
+    time.Sleep(50 * time.Millisecond)
+    m.Stop()
+    <-errc
+
+    if rec.len() != 1 || rec.alerts[0].Num != 1 || rec.alerts[0].Groups[0].Err != "crash loop" {
+        t.Fatalf("failed alert not replayed: %+v", rec.alerts)
+    }
+    if fi, err := os.Stat(path); err != nil || fi.Size() != 0 {
+        t.Errorf("journal not reset after sending: %v", err)
+    }
+}
@@ -0,0 +1,69 @@
+package alerter
+
+import (
+    "context"
+    "errors"
+    "time"
+)
+
+// Alert is a single notification produced by an Alerter when it flushes.
+type Alert struct {
+    Title    string    `json:"title"`
+    Severity Severity  `json:"severity"`
+    Num      int       `json:"num"`
+    Msg      string    `json:"msg"`
+    Groups   []Group   `json:"groups,omitempty"`
+    Resolved bool      `json:"resolved,omitempty"`
+    Time     time.Time `json:"time"`
+}
+
+// Notifier delivers alerts to some backend.
+type Notifier interface {
+    Notify(ctx context.Context, alert *Alert) error
+}
+
+// Notify lets a HandleFunc be used as a Notifier.
+func (f HandleFunc) Notify(_ context.Context, alert *Alert) error {
+    f(alert.Title, alert.Num, alert.Msg)
+    return nil
+}
+
+// AlertFunc is a HandleFunc receiving the whole alert, severity included.
+type AlertFunc func(alert *Alert)
+
+// Notify calls f(alert).
+func (f AlertFunc) Notify(_ context.Context, alert *Alert) error {
+    f(alert)
+    return nil
+}
+
+// NotifierFunc is an adapter to allow ordinary functions as Notifier.
+type NotifierFunc func(ctx context.Context, alert *Alert) error
+
+// Notify calls f(ctx, alert).
+func (f NotifierFunc) Notify(ctx context.Context, alert *Alert) error {
+    return f(ctx, alert)
+}
+
+// MultiNotifier fans an alert out to several notifiers.
+type MultiNotifier []Notifier
+
+// Multi returns a notifier that sends every alert to all ns.
+func Multi(ns ...Notifier) MultiNotifier {
+    return MultiNotifier(ns)
+}
+
+// Notify sends alert to every notifier, even if some fail,
+// and returns the joined errors.
+func (m MultiNotifier) Notify(ctx context.Context, alert *Alert) error {
+    var errs []error
+    for _, n := range m {
+        if n == nil {
+            continue
+        }
+        if err := n.Notify(ctx, alert); err != nil {
+            errs = append(errs, err)
+        }
+    }
+    return errors.Join(errs...)
+}
@@ -0,0 +1,210 @@
+package alerter_test
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "errors"
+    "io"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/kakami/pkg/alerter"
+)
+
+func testAlert() *alerter.Alert {
+    return &alerter.Alert{
+        Title:    "test",
+        Severity: alerter.SeverityError,
+        Num:      2,
+        Msg:      "this is a fatal error\nboom",
+        Time:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+    }
+}
+
+func Test_Webhook(t *testing.T) {
+    var got []byte
+    var ctype string
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        got, _ = io.ReadAll(r.Body)
+        ctype = r.Header.Get("Content-Type")
+    }))
+    defer srv.Close()
+
+    wh, err := alerter.NewWebhook(srv.URL, "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err = wh.Notify(context.Background(), testAlert()); err != nil {
+        t.Fatal(err)
+    }
+    var a alerter.Alert
+    if err = json.Unmarshal(got, &a); err != nil {
+        t.Fatal(err)
+    }
+    if a.Title != "test" || a.Severity != alerter.SeverityError || a.Num != 2 || a.Msg != testAlert().Msg {
+        t.Errorf("unexpected body: %s", got)
+    }
+    if ctype != "application/json" {
+        t.Errorf("unexpected content type: %s", ctype)
+    }
+
+    wh, err = alerter.NewWebhook(srv.URL, `{"text": {{json .Msg}}, "n": {{.Num}}}`)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err = wh.Notify(context.Background(), testAlert()); err != nil {
+        t.Fatal(err)
+    }
+    var m map[string]any
+    if err = json.Unmarshal(got, &m); err != nil {
+        t.Fatalf("%v: %s", err, got)
+    }
+    if m["text"] != testAlert().Msg || m["n"] != float64(2) {
+        t.Errorf("unexpected body: %s", got)
+    }
+}
+
+func Test_WebhookStatus(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusBadGateway)
+    }))
+    defer srv.Close()
+
+    wh, _ := alerter.NewWebhook(srv.URL, "")
+    err := wh.Notify(context.Background(), testAlert())
+    var we *alerter.WebhookError
+    if !errors.As(err, &we) || we.StatusCode != http.StatusBadGateway {
+        t.Errorf("expected webhook error, got %v", err)
+    }
+}
+
+// smtpServer is a minimal SMTP stand-in accepting a single message.
+func smtpServer(t *testing.T) (string, <-chan string) {
+    ln, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    ch := make(chan string, 1)
+    go func() {
+        defer ln.Close()
+        conn, err := ln.Accept()
+        if err != nil {
+            return
+        }
+        defer conn.Close()
+        r := bufio.NewReader(conn)
+        reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+        reply("220 localhost ESMTP")
+        var data strings.Builder
+        for {
+            line, err := r.ReadString('\n')
+            if err != nil {
+                return
+            }
+            cmd := strings.ToUpper(strings.TrimSpace(line))
+            switch {
+            case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+                reply("250 localhost")
+            case cmd == "DATA":
+                reply("354 go ahead")
+                for {
+                    l, err := r.ReadString('\n')
+                    if err != nil {
+                        return
+                    }
+                    if l == ".\r\n" {
+                        break
+                    }
+                    data.WriteString(l)
+                }
+                ch <- data.String()
+                reply("250 ok")
+            case cmd == "QUIT":
+                reply("221 bye")
+                return
+            default:
+                reply("250 ok")
+            }
+        }
+    }()
+    return ln.Addr().String(), ch
+}
+
+func Test_SMTP(t *testing.T) {
+    addr, ch := smtpServer(t)
+    n := alerter.NewSMTP(addr, nil, "alert@example.com", "oncall@example.com")
+    if err := n.Notify(context.Background(), testAlert()); err != nil {
+        t.Fatal(err)
+    }
+    select {
+    case msg := <-ch:
+        if !strings.Contains(msg, "Subject: [error] test (2)") || !strings.Contains(msg, "boom") {
+            t.Errorf("unexpected message: %q", msg)
+        }
+    case <-time.After(time.Second):
+        t.Error("no message received")
+    }
+}
+
+func Test_SMTPTimeout(t *testing.T) {
+    ln, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer ln.Close()
+    // accepts and never answers
+    go func() {
+        for {
+            conn, err := ln.Accept()
+            if err != nil {
+                return
+            }
+            defer conn.Close()
+        }
+    }()
+
+    n := alerter.NewSMTP(ln.Addr().String(), nil, "alert@example.com", "oncall@example.com")
+    ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+    defer cancel()
+    start := time.Now()
+    if err := n.Notify(ctx, testAlert()); !errors.Is(err, context.DeadlineExceeded) {
+        t.Errorf("unexpected error: %v", err)
+    }
+    if d := time.Since(start); d > time.Second {
+        t.Errorf("blocked for %v", d)
+    }
+}
+
+func Test_FileAndMulti(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "alert.log")
+    var called int
+    n := alerter.Multi(
+        alerter.NewFile(path),
+        alerter.NotifierFunc(func(context.Context, *alerter.Alert) error {
+            called++
+            return errors.New("failed")
+        }),
+        alerter.NewFile(path),
+    )
+    if err := n.Notify(context.Background(), testAlert()); err == nil {
+        t.Error("expected error from fan-out")
+    }
+    if called != 1 {
+        t.Errorf("called %d times", called)
+    }
+    data, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+    if len(lines) != 2 || lines[0] != "2024-01-02 03:04:05 error [test] 2: this is a fatal error; boom" {
+        t.Errorf("unexpected file content: %q", data)
+    }
+}
@@ -0,0 +1,128 @@
+package alerter
+
+import (
+    "fmt"
+    "strings"
+    "time"
+)
+
+const (
+    _defaultInterval = 5 * time.Second
+    _defaultSamples  = 9
+)
+
+// Severity is the level an alerter reports its alerts with.
+type Severity int
+
+const (
+    SeverityWarn Severity = iota
+    SeverityError
+    SeverityCritical
+)
+
+func (s Severity) String() string {
+    switch s {
+    case SeverityWarn:
+        return "warn"
+    case SeverityError:
+        return "error"
+    case SeverityCritical:
+        return "critical"
+    }
+    return "unknown"
+}
+
+// ParseSeverity parses a severity name as returned by Severity.String.
+func ParseSeverity(name string) (Severity, error) {
+    switch strings.ToLower(name) {
+    case "warn", "warning":
+        return SeverityWarn, nil
+    case "error":
+        return SeverityError, nil
+    case "critical":
+        return SeverityCritical, nil
+    }
+    return SeverityError, fmt.Errorf("alerter: unknown severity %q", name)
+}
+
+// MarshalText encodes the severity as its name.
+func (s Severity) MarshalText() ([]byte, error) {
+    return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity name.
+func (s *Severity) UnmarshalText(text []byte) error {
+    v, err := ParseSeverity(string(text))
+    if err != nil {
+        return err
+    }
+    *s = v
+    return nil
+}
+
+// Option is alerter option.
+type Option func(*options)
+
+type options struct {
+    interval time.Duration
+    samples  int
+    severity Severity
+    journal  string
+    handler  AlertFunc
+    policy
+}
+
+func defaultOptions() options {
+    return options{
+        interval: _defaultInterval,
+        samples:  _defaultSamples,
+        severity: SeverityError,
+    }
+}
+
+// WithInterval sets how often pending errors are flushed (default 5s).
+// Intervals below one second are rounded up to one second.
+func WithInterval(d time.Duration) Option {
+    return func(o *options) {
+        if d < time.Second {
+            d = time.Second
+        }
+        o.interval = d
+    }
+}
+
+// WithSamples sets how many distinct error groups are kept in each alert
+// message (default 9). Errors beyond that are still counted.
+func WithSamples(n int) Option {
+    return func(o *options) {
+        if n < 0 {
+            n = 0
+        }
+        o.samples = n
+    }
+}
+
+// WithSeverity sets the severity reported with every alert (default error).
+func WithSeverity(s Severity) Option {
+    return func(o *options) {
+        o.severity = s
+    }
+}
+
+// WithHandlerFunc also calls f with every alert, e.g. for alerters created
+// by New whose HandleFunc does not get the severity.
+func WithHandlerFunc(f AlertFunc) Option {
+    return func(o *options) {
+        o.handler = f
+    }
+}
+
+// WithJournal appends every recorded error to the file at path until it is
+// delivered, so that errors pending when the process dies are replayed by the
+// next alerter created with the same journal and sent on Manager.Start.
+// Journal I/O is best effort and never fails Fatal.
+func WithJournal(path string) Option {
+    return func(o *options) {
+        o.journal = path
+    }
+}
@@ -0,0 +1,141 @@
+package alerter
+
+import (
+    "time"
+)
+
+type policy struct {
+    backoffMax    time.Duration
+    escalateAfter int
+    escalation    Notifier
+    resolve       bool
+    quiet         bool
+    quietStart    time.Duration
+    quietEnd      time.Duration
+}
+
+// WithBackoff doubles the delay between two alerts of the same alerter for
+// as long as errors keep coming in, starting at the flush interval and
+// capped at max. The delay is reset once a window passes without errors.
+func WithBackoff(max time.Duration) Option {
+    return func(o *options) {
+        o.backoffMax = max
+    }
+}
+
+// WithEscalation additionally sends alerts to n once errors were seen in
+// `after` consecutive windows.
+func WithEscalation(after int, n Notifier) Option {
+    return func(o *options) {
+        o.escalateAfter = after
+        o.escalation = n
+    }
+}
+
+// WithResolve sends an alert with Resolved set when a window passes with
+// no errors after an alert was sent.
+func WithResolve() Option {
+    return func(o *options) {
+        o.resolve = true
+    }
+}
+
+// WithQuietHours holds alerts between start and end, given as offsets from
+// local midnight; the range may wrap around midnight. Errors keep being
+// recorded and are sent once the quiet hours are over. Alerters with
+// SeverityCritical are never held.
+func WithQuietHours(start, end time.Duration) Option {
+    return func(o *options) {
+        o.quiet = start != end
+        o.quietStart = start
+        o.quietEnd = end
+    }
+}
+
+// backoff returns the minimum delay after the sent-th alert of an incident.
+func (o *options) backoff(sent int) time.Duration {
+    if o.backoffMax <= 0 || sent < 1 {
+        return 0
+    }
+    d := o.interval
+    for i := 1; i < sent && d < o.backoffMax; i++ {
+        d *= 2
+    }
+    return min(d, o.backoffMax)
+}
+
+func (p *policy) inQuietHours(now time.Time) bool {
+    if !p.quiet {
+        return false
+    }
+    h, m, s := now.Clock()
+    tod := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+    if p.quietStart < p.quietEnd {
+        return tod >= p.quietStart && tod < p.quietEnd
+    }
+    return tod >= p.quietStart || tod < p.quietEnd
+}
+
+func (a *Alerter) escalating() bool {
+    return a.opts.escalation != nil && a.opts.escalateAfter > 0 && a.streak >= a.opts.escalateAfter
+}
+
+// tick closes the current window if the flush interval elapsed and sends
+// whatever the policy allows.
+func (a *Alerter) tick(now time.Time) {
+    a.mu.Lock()
+    // allow half a tick of jitter so a 5s interval does not slip to 6s
+    if now.Sub(a.last)+_tick/2 < a.opts.interval {
+        a.mu.Unlock()
+        return
+    }
+    a.last = now
+    // errors may have been drained by SendAlert, only resolve after a
+    // window without any
+    quiet := a.window == 0
+    if quiet {
+        a.streak = 0
+    } else {
+        a.streak++
+    }
+    a.window = 0
+
+    var alert *Alert
+    var t *taken
+    escalate := false
+    switch {
+    case a.total == 0:
+        if a.firing && quiet {
+            alert, escalate = a.resolved(now)
+        }
+    case a.opts.severity < SeverityCritical && a.opts.inQuietHours(now):
+    case now.Before(a.next):
+    default:
+        alert, t = a.take(now)
+        escalate = a.escalating()
+        a.escalated = a.escalated || escalate
+    }
+    a.mu.Unlock()
+
+    a.settle(t, a.notify(alert, escalate))
+}
+
+// resolved ends the current incident. It returns the resolved alert, if
+// enabled, and whether it goes to the escalation notifier too. a.mu must be held.
+func (a *Alerter) resolved(now time.Time) (*Alert, bool) {
+    escalated := a.escalated
+    a.firing = false
+    a.escalated = false
+    a.sent = 0
+    a.next = time.Time{}
+    if !a.opts.resolve {
+        return nil, false
+    }
+    return &Alert{
+        Title:    a.title,
+        Severity: a.opts.severity,
+        Msg:      "resolved",
+        Resolved: true,
+        Time:     now,
+    }, escalated
+}
@@ -0,0 +1,148 @@
+package alerter
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+)
+
+type countNotifier struct {
+    alerts []*Alert
+}
+
+func (c *countNotifier) Notify(_ context.Context, a *Alert) error {
+    c.alerts = append(c.alerts, a)
+    return nil
+}
+
+func Test_PolicyBackoff(t *testing.T) {
+    n := &countNotifier{}
+    a := newAlerter("backoff", n, WithInterval(time.Second), WithBackoff(4*time.Second), WithResolve())
+    now := a.last
+    var sentAt []int
+    for i := 1; i <= 12; i++ {
+        now = now.Add(time.Second)
+        a.Fatal(errors.New("again"))
+        before := len(n.alerts)
+        a.tick(now)
+        if len(n.alerts) > before {
+            sentAt = append(sentAt, i)
+        }
+    }
+    // delays 1s, 2s, 4s, 4s
+    want := []int{1, 2, 4, 8, 12}
+    if len(sentAt) != len(want) {
+        t.Fatalf("sent at %v, want %v", sentAt, want)
+    }
+    for i := range want {
+        if sentAt[i] != want[i] {
+            t.Fatalf("sent at %v, want %v", sentAt, want)
+        }
+    }
+    if n.alerts[2].Num != 2 {
+        t.Errorf("held errors not accumulated: %d", n.alerts[2].Num)
+    }
+
+    now = now.Add(time.Second)
+    a.tick(now)
+    last := n.alerts[len(n.alerts)-1]
+    if !last.Resolved {
+        t.Fatalf("expected resolved alert, got %+v", last)
+    }
+    now = now.Add(time.Second)
+    a.Fatal(errors.New("again"))
+    a.tick(now)
+    now = now.Add(time.Second)
+    a.Fatal(errors.New("again"))
+    a.tick(now)
+    if got := len(n.alerts); got != 8 {
+        t.Errorf("back-off not reset after resolve, %d alerts", got)
+    }
+}
+
+func Test_PolicyEscalation(t *testing.T) {
+    n, esc := &countNotifier{}, &countNotifier{}
+    a := newAlerter("escalation", n, WithInterval(time.Second), WithEscalation(3, esc), WithResolve())
+    now := a.last
+    for i := 0; i < 4; i++ {
+        now = now.Add(time.Second)
+        a.Fatal(errors.New("again"))
+        a.tick(now)
+    }
+    if len(n.alerts) != 4 || len(esc.alerts) != 2 {
+        t.Fatalf("got %d alerts, %d escalated", len(n.alerts), len(esc.alerts))
+    }
+    now = now.Add(time.Second)
+    a.tick(now)
+    if len(esc.alerts) != 3 || !esc.alerts[2].Resolved {
+        t.Errorf("resolved alert not escalated")
+    }
+}
+
+func Test_PolicyQuietHours(t *testing.T) {
+    n := &countNotifier{}
+    a := newAlerter("quiet", n, WithInterval(time.Second), WithQuietHours(22*time.Hour, 7*time.Hour))
+    now := time.Date(2024, 1, 1, 23, 0, 0, 0, time.Local)
+    a.last = now
+    for i := 0; i < 3; i++ {
+        now = now.Add(time.Second)
+        a.Fatal(errors.New("night"))
+        a.tick(now)
+    }
+    if len(n.alerts) != 0 {
+        t.Fatalf("alert sent during quiet hours")
+    }
+    now = time.Date(2024, 1, 2, 7, 0, 0, 0, time.Local)
+    a.tick(now)
+    if len(n.alerts) != 1 || n.alerts[0].Num != 3 {
+        t.Fatalf("held alerts not sent after quiet hours: %+v", n.alerts)
+    }
+
+    crit := newAlerter("quiet", n, WithInterval(time.Second), WithSeverity(SeverityCritical),
+        WithQuietHours(22*time.Hour, 7*time.Hour))
+    now = time.Date(2024, 1, 2, 23, 0, 0, 0, time.Local)
+    crit.last = now
+    crit.Fatal(errors.New("night"))
+    crit.tick(now.Add(time.Second))
+    if len(n.alerts) != 2 {
+        t.Errorf("critical alert held during quiet hours")
+    }
+}
+
+func Test_PolicySendAlert(t *testing.T) {
+    n, esc := &countNotifier{}, &countNotifier{}
+    a := newAlerter("send", n, WithInterval(time.Second), WithBackoff(time.Hour),
+        WithEscalation(2, esc), WithResolve())
+    now := a.last
+    now = now.Add(time.Second)
+    a.Fatal(errors.New("again"))
+    a.tick(now)
+    // held by back-off, then sent right away
+    now = now.Add(600 * time.Millisecond)
+    a.Fatal(errors.New("again"))
+    a.tick(now)
+    a.SendAlert()
+    if len(n.alerts) != 2 || len(esc.alerts) != 1 {
+        t.Fatalf("got %d alerts, %d escalated", len(n.alerts), len(esc.alerts))
+    }
+    now = now.Add(time.Second)
+    a.tick(now)
+    if len(esc.alerts) != 2 || !esc.alerts[1].Resolved {
+        t.Fatalf("resolved alert not escalated: %+v", esc.alerts)
+    }
+
+    // errors drained by SendAlert still keep the window open
+    a.Fatal(errors.New("again"))
+    a.SendAlert()
+    now = now.Add(time.Second)
+    a.tick(now)
+    if last := n.alerts[len(n.alerts)-1]; last.Resolved {
+        t.Fatalf("resolved in a window with errors")
+    }
+    now = now.Add(time.Second)
+    a.tick(now)
+    if last := n.alerts[len(n.alerts)-1]; !last.Resolved {
+        t.Fatalf("not resolved after a quiet window")
+    }
+}
@@ -0,0 +1,114 @@
+package alerter
+
+import (
+    "bytes"
+    "context"
+    "crypto/tls"
+    "fmt"
+    "net"
+    "net/smtp"
+    "strings"
+    "time"
+)
+
+// SMTPNotifier mails alerts through an SMTP relay.
+type SMTPNotifier struct {
+    addr string
+    auth smtp.Auth
+    from string
+    to   []string
+}
+
+// NewSMTP creates a notifier sending from `from` to every address in `to`
+// via the server at addr (host:port). auth may be nil.
+func NewSMTP(addr string, auth smtp.Auth, from string, to ...string) *SMTPNotifier {
+    return &SMTPNotifier{
+        addr: addr,
+        auth: auth,
+        from: from,
+        to:   to,
+    }
+}
+
+// Notify implements Notifier. The whole exchange with the relay is bound
+// to ctx.
+func (s *SMTPNotifier) Notify(ctx context.Context, alert *Alert) error {
+    if len(s.to) == 0 {
+        return fmt.Errorf("alerter: smtp notifier has no recipients")
+    }
+    conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", s.addr)
+    if err != nil {
+        return err
+    }
+    defer conn.Close()
+    if deadline, ok := ctx.Deadline(); ok {
+        conn.SetDeadline(deadline)
+    }
+    // unblock a relay that stops answering when ctx is canceled
+    stop := context.AfterFunc(ctx, func() {
+        conn.SetDeadline(time.Unix(1, 0))
+    })
+    defer stop()
+    if err = s.send(conn, alert); err != nil && ctx.Err() != nil {
+        return ctx.Err()
+    }
+    return err
+}
+
+// send does what smtp.SendMail does over conn.
+func (s *SMTPNotifier) send(conn net.Conn, alert *Alert) error {
+    host, _, _ := net.SplitHostPort(s.addr)
+    c, err := smtp.NewClient(conn, host)
+    if err != nil {
+        return err
+    }
+    defer c.Close()
+    if ok, _ := c.Extension("STARTTLS"); ok {
+        if err = c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+            return err
+        }
+    }
+    if s.auth != nil {
+        if ok, _ := c.Extension("AUTH"); ok {
+            if err = c.Auth(s.auth); err != nil {
+                return err
+            }
+        }
+    }
+    if err = c.Mail(s.from); err != nil {
+        return err
+    }
+    for _, rcpt := range s.to {
+        if err = c.Rcpt(rcpt); err != nil {
+            return err
+        }
+    }
+    w, err := c.Data()
+    if err != nil {
+        return err
+    }
+    if _, err = w.Write(s.message(alert)); err != nil {
+        return err
+    }
+    if err = w.Close(); err != nil {
+        return err
+    }
+    return c.Quit()
+}
+
+func (s *SMTPNotifier) message(alert *Alert) []byte {
+    var buf bytes.Buffer
+    fmt.Fprintf(&buf, "From: %s\r\n", s.from)
+    fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(s.to, ", "))
+    fmt.Fprintf(&buf, "Subject: [%s] %s (%d)\r\n", alert.Severity, alert.Title, alert.Num)
+    fmt.Fprintf(&buf, "Date: %s\r\n", alert.Time.Format(time.RFC1123Z))
+    buf.WriteString("MIME-Version: 1.0\r\n")
+    buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+    buf.WriteString("\r\n")
+    for _, line := range strings.Split(alert.Msg, "\n") {
+        // dot-stuffing is done by net/smtp, only normalize line endings here
+        buf.WriteString(strings.TrimRight(line, "\r"))
+        buf.WriteString("\r\n")
+    }
+    return buf.Bytes()
+}
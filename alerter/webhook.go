@@ -0,0 +1,120 @@
+package alerter
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "text/template"
+    "time"
+)
+
+const (
+    _defaultWebhookTimeout = 10 * time.Second
+)
+
+// WebhookNotifier posts alerts to an HTTP endpoint.
+//
+// Without a template the body is the JSON encoding of Alert. A template is
+// executed with the Alert as data; the "json" function quotes a value so
+// that custom JSON bodies stay valid, e.g.
+//
+//  {"text": {{json .Title}}}
+type WebhookNotifier struct {
+    url    string
+    method string
+    header http.Header
+    tmpl   *template.Template
+    client *http.Client
+}
+
+// WebhookOption is webhook notifier option.
+type WebhookOption func(*WebhookNotifier)
+
+// WithWebhookMethod sets the HTTP method, POST by default.
+func WithWebhookMethod(method string) WebhookOption {
+    return func(w *WebhookNotifier) {
+        w.method = method
+    }
+}
+
+// WithWebhookHeader adds a request header.
+func WithWebhookHeader(key, value string) WebhookOption {
+    return func(w *WebhookNotifier) {
+        w.header.Add(key, value)
+    }
+}
+
+// WithWebhookClient sets the HTTP client used to deliver alerts.
+func WithWebhookClient(c *http.Client) WebhookOption {
+    return func(w *WebhookNotifier) {
+        w.client = c
+    }
+}
+
+// NewWebhook creates a webhook notifier. tmpl may be empty to send the
+// default JSON body.
+func NewWebhook(url, tmpl string, opts ...WebhookOption) (*WebhookNotifier, error) {
+    w := &WebhookNotifier{
+        url:    url,
+        method: http.MethodPost,
+        header: http.Header{"Content-Type": []string{"application/json"}},
+        client: &http.Client{Timeout: _defaultWebhookTimeout},
+    }
+    if tmpl != "" {
+        t, err := template.New("webhook").Funcs(template.FuncMap{"json": jsonQuote}).Parse(tmpl)
+        if err != nil {
+            return nil, fmt.Errorf("alerter: parse webhook template: %w", err)
+        }
+        w.tmpl = t
+    }
+    for _, o := range opts {
+        o(w)
+    }
+    return w, nil
+}
+
+// Notify implements Notifier.
+func (w *WebhookNotifier) Notify(ctx context.Context, alert *Alert) error {
+    body, err := w.body(alert)
+    if err != nil {
+        return err
+    }
+    req, err := http.NewRequestWithContext(ctx, w.method, w.url, bytes.NewReader(body))
+    if err != nil {
+        return err
+    }
+    for k, vs := range w.header {
+        for _, v := range vs {
+            req.Header.Add(k, v)
+        }
+    }
+    resp, err := w.client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    _, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+    if resp.StatusCode < 200 || resp.StatusCode > 299 {
+        return &WebhookError{StatusCode: resp.StatusCode}
+    }
+    return nil
+}
+
+func (w *WebhookNotifier) body(alert *Alert) ([]byte, error) {
+    if w.tmpl == nil {
+        return json.Marshal(alert)
+    }
+    var buf bytes.Buffer
+    if err := w.tmpl.Execute(&buf, alert); err != nil {
+        return nil, fmt.Errorf("alerter: execute webhook template: %w", err)
+    }
+    return buf.Bytes(), nil
+}
+
+func jsonQuote(v any) (string, error) {
+    b, err := json.Marshal(v)
+    return string(b), err
+}
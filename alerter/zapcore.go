@@ -0,0 +1,115 @@
+package alerter
+
+import (
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+
+    "go.uber.org/zap"
+    "go.uber.org/zap/zapcore"
+)
+
+// Core is a zapcore.Core that records log entries into an Alerter.
+type Core struct {
+    a      *Alerter
+    lvl    zapcore.LevelEnabler
+    tags   []string
+    fields []zapcore.Field
+}
+
+// NewCore returns a core forwarding entries enabled by lvl to a. If tags
+// are given, only loggers created by zlog with one of these tags are
+// forwarded.
+func NewCore(a *Alerter, lvl zapcore.LevelEnabler, tags ...string) *Core {
+    ptags := make([]string, 0, len(tags))
+    for _, tag := range tags {
+        ptags = append(ptags, "["+tag+"]")
+    }
+    return &Core{
+        a:    a,
+        lvl:  lvl,
+        tags: ptags,
+    }
+}
+
+// Hook returns a zap option teeing the logger into a, e.g.
+//
+//  logger := zlog.LoggerWithTag("rtmp").WithOptions(alerter.Hook(alt, zapcore.ErrorLevel))
+func Hook(a *Alerter, lvl zapcore.LevelEnabler, tags ...string) zap.Option {
+    return zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+        return zapcore.NewTee(c, NewCore(a, lvl, tags...))
+    })
+}
+
+// Enabled implements zapcore.Core.
+func (c *Core) Enabled(l zapcore.Level) bool {
+    return c.lvl.Enabled(l)
+}
+
+// With implements zapcore.Core.
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+    clone := *c
+    clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+    clone.fields = append(clone.fields, c.fields...)
+    clone.fields = append(clone.fields, fields...)
+    return &clone
+}
+
+// Check implements zapcore.Core.
+func (c *Core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+    if c.Enabled(ent.Level) && c.match(ent.LoggerName) {
+        return ce.AddCore(ent, c)
+    }
+    return ce
+}
+
+// Write implements zapcore.Core.
+func (c *Core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+    enc := zapcore.NewMapObjectEncoder()
+    for _, f := range c.fields {
+        f.AddTo(enc)
+    }
+    for _, f := range fields {
+        f.AddTo(enc)
+    }
+
+    var sb strings.Builder
+    if ent.LoggerName != "" {
+        sb.WriteString(ent.LoggerName)
+        sb.WriteString(" ")
+    }
+    sb.WriteString(ent.Level.String())
+    sb.WriteString(": ")
+    sb.WriteString(ent.Message)
+    keys := make([]string, 0, len(enc.Fields))
+    for k := range enc.Fields {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    for _, k := range keys {
+        fmt.Fprintf(&sb, " %s=%v", k, enc.Fields[k])
+    }
+
+    // group by logger and message only, field values usually vary
+    key := Fingerprint(errors.New(ent.LoggerName + ": " + ent.Message))
+    c.a.FatalWithKey(key, errors.New(sb.String()))
+    return nil
+}
+
+// Sync implements zapcore.Core.
+func (c *Core) Sync() error {
+    return nil
+}
+
+func (c *Core) match(name string) bool {
+    if len(c.tags) == 0 {
+        return true
+    }
+    for _, tag := range c.tags {
+        if strings.Contains(name, tag) {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,47 @@
+package alerter_test
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "strings"
+    "testing"
+
+    "go.uber.org/zap"
+    "go.uber.org/zap/zapcore"
+
+    "github.com/kakami/pkg/alerter"
+    "github.com/kakami/pkg/zlog"
+)
+
+func Test_ZapCore(t *testing.T) {
+    var got *alerter.Alert
+    m := alerter.NewManager()
+    alt := m.NewWithNotifier("zap", alerter.NotifierFunc(func(_ context.Context, a *alerter.Alert) error {
+        got = a
+        return nil
+    }))
+
+    var buf bytes.Buffer
+    ls := zlog.New(&buf, zapcore.DebugLevel)
+    hook := alerter.Hook(alt, zapcore.ErrorLevel, "rtmp")
+    rtmp := ls.LoggerWithTag("rtmp").WithOptions(hook).With(zap.String("stream", "live/1"))
+    other := ls.LoggerWithTag("other").WithOptions(hook)
+
+    rtmp.Info("connected")
+    rtmp.Error("publish failed", zap.Error(errors.New("eof")), zap.Int("retry", 1))
+    rtmp.Error("publish failed", zap.Error(errors.New("eof")), zap.Int("retry", 2))
+    other.Error("ignored")
+    m.Flush()
+
+    if got == nil || got.Num != 2 || len(got.Groups) != 1 {
+        t.Fatalf("unexpected alert: %+v", got)
+    }
+    want := "[rtmp] error: publish failed error=eof retry=1 stream=live/1"
+    if got.Groups[0].Err != want {
+        t.Errorf("got %q, want %q", got.Groups[0].Err, want)
+    }
+    if !strings.Contains(buf.String(), "publish failed") {
+        t.Errorf("log entry not written to the original core")
+    }
+}
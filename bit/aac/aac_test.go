@@ -0,0 +1,166 @@
+package aac_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kakami/pkg/bit/aac"
+)
+
+func Test_ParseConfig(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     []byte
+		typ      aac.ObjectType
+		rate     int
+		out      int
+		channels int
+		sbr, ps  bool
+	}{
+		{"lc 44100", []byte{0x12, 0x10}, aac.ObjectTypeAACLC, 44100, 44100, 2, false, false},
+		{"lc 48000", []byte{0x11, 0x90}, aac.ObjectTypeAACLC, 48000, 48000, 2, false, false},
+		{"lc mono", []byte{0x15, 0x88}, aac.ObjectTypeAACLC, 8000, 8000, 1, false, false},
+		{"he-aac compatible", []byte{0x13, 0x10, 0x56, 0xe5, 0x98}, aac.ObjectTypeAACLC, 24000, 48000, 2, true, false},
+		{"he-aac hierarchical", []byte{0x2b, 0x11, 0x88, 0x00}, aac.ObjectTypeAACLC, 24000, 48000, 2, true, false},
+		{"he-aac v2", []byte{0xeb, 0x09, 0x88, 0x00}, aac.ObjectTypeAACLC, 24000, 48000, 2, true, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg, err := aac.ParseConfig(c.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.ObjectType != c.typ || cfg.SampleRate != c.rate || cfg.OutputSampleRate() != c.out ||
+				cfg.Channels() != c.channels || cfg.SBR != c.sbr || cfg.PS != c.ps {
+				t.Fatalf("got %+v", cfg)
+			}
+		})
+	}
+
+	if _, err := aac.ParseConfig([]byte{0x12}); err == nil {
+		t.Fatal("expected error for truncated config")
+	}
+	if _, err := aac.ParseConfig([]byte{0x00, 0x00}); err == nil {
+		t.Fatal("expected error for object type 0")
+	}
+}
+
+func Test_ConfigMarshal(t *testing.T) {
+	for _, data := range [][]byte{{0x12, 0x10}, {0x11, 0x90}, {0x2b, 0x11, 0x88, 0x00}, {0xeb, 0x09, 0x88, 0x00}} {
+		cfg, err := aac.ParseConfig(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := cfg.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b, data) {
+			t.Fatalf("marshal %x, want %x", b, data)
+		}
+	}
+
+	// backward compatible signalling is rewritten as hierarchical
+	cfg, _ := aac.ParseConfig([]byte{0x13, 0x10, 0x56, 0xe5, 0x98})
+	b, _ := cfg.Marshal()
+	again, err := aac.ParseConfig(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *again != *cfg {
+		t.Fatalf("round trip %+v, want %+v", again, cfg)
+	}
+
+	// sample rates outside the table use the explicit escape
+	cfg = &aac.Config{ObjectType: aac.ObjectTypeAACLC, SampleRate: 50000, ChannelConfig: 2}
+	b, _ = cfg.Marshal()
+	again, err = aac.ParseConfig(b)
+	if err != nil || again.SampleRate != 50000 {
+		t.Fatalf("explicit rate %+v %v", again, err)
+	}
+}
+
+func Test_ADTSHeader(t *testing.T) {
+	data := []byte{0xff, 0xf1, 0x50, 0x80, 0x2e, 0x7f, 0xfc}
+	h, err := aac.ParseADTSHeader(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := aac.ADTSHeader{
+		ProtectionAbsent: true,
+		ObjectType:       aac.ObjectTypeAACLC,
+		SampleRate:       44100,
+		ChannelConfig:    2,
+		FrameLength:      371,
+		BufferFullness:   0x7ff,
+		RawDataBlocks:    1,
+	}
+	if *h != want {
+		t.Fatalf("got %+v, want %+v", h, want)
+	}
+	if h.HeaderLen() != 7 || h.PayloadLen() != 364 {
+		t.Fatalf("header %d payload %d", h.HeaderLen(), h.PayloadLen())
+	}
+	b, err := h.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, data) {
+		t.Fatalf("marshal %x, want %x", b, data)
+	}
+
+	asc, _ := h.Config().Marshal()
+	if !bytes.Equal(asc, []byte{0x12, 0x10}) {
+		t.Fatalf("config %x", asc)
+	}
+
+	if _, err := aac.ParseADTSHeader([]byte{0xff, 0xf1, 0x50}); err == nil {
+		t.Fatal("expected error for short header")
+	}
+	if _, err := aac.ParseADTSHeader([]byte{0x12, 0x10, 0, 0, 0, 0, 0}); err != aac.ErrNoSync {
+		t.Fatalf("got %v, want ErrNoSync", err)
+	}
+
+	h.ProtectionAbsent = false
+	h.CRC = 0xbeef
+	h.FrameLength = 20
+	b, _ = h.Marshal()
+	got, err := aac.ParseADTSHeader(b)
+	if err != nil || *got != *h || got.HeaderLen() != 9 {
+		t.Fatalf("crc round trip %+v %v", got, err)
+	}
+}
+
+func Test_SplitADTS(t *testing.T) {
+	cfg, _ := aac.ParseConfig([]byte{0x11, 0x90})
+	var stream []byte
+	payloads := [][]byte{{1, 2, 3}, {4, 5, 6, 7, 8}, {9}}
+	for _, p := range payloads {
+		var err error
+		if stream, err = aac.AppendADTS(stream, cfg, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	frames, n, err := aac.SplitADTS(stream[:len(stream)-3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 2 || n != 7+3+7+5 {
+		t.Fatalf("got %d frames, %d bytes", len(frames), n)
+	}
+
+	frames, n, err = aac.SplitADTS(stream)
+	if err != nil || n != len(stream) || len(frames) != len(payloads) {
+		t.Fatalf("got %d frames, %d bytes, %v", len(frames), n, err)
+	}
+	for i, f := range frames {
+		if !bytes.Equal(f.Payload, payloads[i]) || f.Header.SampleRate != 48000 {
+			t.Fatalf("frame %d: %+v %x", i, f.Header, f.Payload)
+		}
+	}
+
+	if _, err := aac.AppendADTS(nil, &aac.Config{ObjectType: aac.ObjectTypeSBR, SampleRate: 48000}, nil); err == nil {
+		t.Fatal("expected error for SBR object type")
+	}
+}
@@ -0,0 +1,190 @@
+package aac
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kakami/pkg/bit"
+)
+
+const (
+	// ADTSHeaderLen is the length of an ADTS header without CRC.
+	ADTSHeaderLen = 7
+	_adtsSyncWord = 0xfff
+)
+
+// ErrNoSync is returned when data does not start with an ADTS sync word.
+var ErrNoSync = errors.New("aac: no ADTS sync word")
+
+// ADTSHeader is an ADTS fixed and variable header, ISO/IEC 13818-7 6.2.
+type ADTSHeader struct {
+	MPEG2            bool // ID, MPEG-2 instead of MPEG-4
+	ProtectionAbsent bool
+	ObjectType       ObjectType
+	SampleRate       int
+	ChannelConfig    uint8
+	FrameLength      int // header and payload
+	BufferFullness   uint16
+	RawDataBlocks    int // AAC frames in the ADTS frame
+	CRC              uint16
+}
+
+// HeaderLen returns 7, or 9 if a CRC is present.
+func (h *ADTSHeader) HeaderLen() int {
+	if h.ProtectionAbsent {
+		return ADTSHeaderLen
+	}
+	return ADTSHeaderLen + 2
+}
+
+// PayloadLen returns the length of the raw data following the header.
+func (h *ADTSHeader) PayloadLen() int {
+	return h.FrameLength - h.HeaderLen()
+}
+
+// ParseADTSHeader parses the header at the start of b.
+func ParseADTSHeader(b []byte) (*ADTSHeader, error) {
+	if len(b) < ADTSHeaderLen {
+		return nil, bit.ErrShortBuffer
+	}
+	r := bit.NewReader(b[:min(len(b), ADTSHeaderLen+2)])
+	if r.Read(12) != _adtsSyncWord {
+		return nil, ErrNoSync
+	}
+	h := &ADTSHeader{}
+	h.MPEG2 = r.Read(1) == 1
+	r.Skip(2) // layer
+	h.ProtectionAbsent = r.Read(1) == 1
+	h.ObjectType = ObjectType(r.Read(2) + 1)
+	idx := r.Read(4)
+	if int(idx) >= len(SampleRates) {
+		return nil, fmt.Errorf("aac: invalid ADTS sampling frequency index %d", idx)
+	}
+	h.SampleRate = SampleRates[idx]
+	r.Skip(1) // private_bit
+	h.ChannelConfig = uint8(r.Read(3))
+	r.Skip(4) // original_copy, home, copyright_identification_bit/start
+	h.FrameLength = int(r.Read(13))
+	h.BufferFullness = uint16(r.Read(11))
+	h.RawDataBlocks = int(r.Read(2)) + 1
+	if !h.ProtectionAbsent {
+		if len(b) < ADTSHeaderLen+2 {
+			return nil, bit.ErrShortBuffer
+		}
+		h.CRC = uint16(r.Read(16))
+	}
+	if h.FrameLength < h.HeaderLen() {
+		return nil, fmt.Errorf("aac: invalid ADTS frame length %d", h.FrameLength)
+	}
+	return h, nil
+}
+
+// Marshal encodes the header, FrameLength must already include it.
+func (h *ADTSHeader) Marshal() ([]byte, error) {
+	idx := SampleRateIndex(h.SampleRate)
+	if idx < 0 {
+		return nil, fmt.Errorf("aac: sample rate %d not allowed in ADTS", h.SampleRate)
+	}
+	if h.ObjectType < 1 || h.ObjectType > 4 {
+		return nil, fmt.Errorf("aac: object type %d not allowed in ADTS", h.ObjectType)
+	}
+	if h.FrameLength < h.HeaderLen() || h.FrameLength >= 1<<13 {
+		return nil, fmt.Errorf("aac: invalid ADTS frame length %d", h.FrameLength)
+	}
+	if h.RawDataBlocks < 1 || h.RawDataBlocks > 4 || h.ChannelConfig > 7 {
+		return nil, fmt.Errorf("aac: invalid ADTS header")
+	}
+	w := bit.NewWriter()
+	w.Write(_adtsSyncWord, 12)
+	w.WriteBool(h.MPEG2)
+	w.Write(0, 2)
+	w.WriteBool(h.ProtectionAbsent)
+	w.Write(uint64(h.ObjectType-1), 2)
+	w.Write(uint64(idx), 4)
+	w.Write(0, 1)
+	w.Write(uint64(h.ChannelConfig), 3)
+	w.Write(0, 4)
+	w.Write(uint64(h.FrameLength), 13)
+	w.Write(uint64(h.BufferFullness), 11)
+	w.Write(uint64(h.RawDataBlocks-1), 2)
+	if !h.ProtectionAbsent {
+		w.Write(uint64(h.CRC), 16)
+	}
+	return w.Bytes(), nil
+}
+
+// Config converts the header to an AudioSpecificConfig.
+func (h *ADTSHeader) Config() *Config {
+	return &Config{
+		ObjectType:    h.ObjectType,
+		SampleRate:    h.SampleRate,
+		ChannelConfig: h.ChannelConfig,
+	}
+}
+
+// NewADTSHeader returns the header for a payload of payloadLen bytes
+// encoded as described by c. ADTS can only carry the core codec, SBR and
+// PS are left to implicit signalling.
+func NewADTSHeader(c *Config, payloadLen int) (*ADTSHeader, error) {
+	if c.ObjectType < 1 || c.ObjectType > 4 {
+		return nil, fmt.Errorf("aac: object type %s not allowed in ADTS", c.ObjectType)
+	}
+	if SampleRateIndex(c.SampleRate) < 0 {
+		return nil, fmt.Errorf("aac: sample rate %d not allowed in ADTS", c.SampleRate)
+	}
+	return &ADTSHeader{
+		ProtectionAbsent: true,
+		ObjectType:       c.ObjectType,
+		SampleRate:       c.SampleRate,
+		ChannelConfig:    c.ChannelConfig,
+		FrameLength:      ADTSHeaderLen + payloadLen,
+		BufferFullness:   0x7ff, // variable bit rate
+		RawDataBlocks:    1,
+	}, nil
+}
+
+// ADTSFrame is one ADTS frame split from a stream.
+type ADTSFrame struct {
+	Header  *ADTSHeader
+	Payload []byte
+}
+
+// SplitADTS splits consecutive ADTS frames. It returns the frames found
+// and the number of bytes consumed; a trailing incomplete frame is left
+// for the next call.
+func SplitADTS(data []byte) ([]ADTSFrame, int, error) {
+	var frames []ADTSFrame
+	pos := 0
+	for len(data)-pos >= ADTSHeaderLen {
+		h, err := ParseADTSHeader(data[pos:])
+		if errors.Is(err, bit.ErrShortBuffer) {
+			break
+		}
+		if err != nil {
+			return frames, pos, err
+		}
+		if pos+h.FrameLength > len(data) {
+			break
+		}
+		frames = append(frames, ADTSFrame{
+			Header:  h,
+			Payload: data[pos+h.HeaderLen() : pos+h.FrameLength],
+		})
+		pos += h.FrameLength
+	}
+	return frames, pos, nil
+}
+
+// AppendADTS appends payload with an ADTS header for c to dst.
+func AppendADTS(dst []byte, c *Config, payload []byte) ([]byte, error) {
+	h, err := NewADTSHeader(c, len(payload))
+	if err != nil {
+		return dst, err
+	}
+	hdr, err := h.Marshal()
+	if err != nil {
+		return dst, err
+	}
+	dst = append(dst, hdr...)
+	return append(dst, payload...), nil
+}
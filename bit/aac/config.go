@@ -0,0 +1,222 @@
+// Package aac parses and generates AAC ADTS headers and MPEG-4
+// AudioSpecificConfig.
+package aac
+
+import (
+	"fmt"
+
+	"github.com/kakami/pkg/bit"
+)
+
+// ObjectType is an MPEG-4 audio object type, ISO/IEC 14496-3 1.5.1.1.
+type ObjectType uint8
+
+// Audio object types
+const (
+	ObjectTypeAACMain ObjectType = 1
+	ObjectTypeAACLC   ObjectType = 2
+	ObjectTypeAACSSR  ObjectType = 3
+	ObjectTypeAACLTP  ObjectType = 4
+	ObjectTypeSBR     ObjectType = 5
+	ObjectTypePS      ObjectType = 29
+)
+
+func (t ObjectType) String() string {
+	switch t {
+	case ObjectTypeAACMain:
+		return "AAC Main"
+	case ObjectTypeAACLC:
+		return "AAC LC"
+	case ObjectTypeAACSSR:
+		return "AAC SSR"
+	case ObjectTypeAACLTP:
+		return "AAC LTP"
+	case ObjectTypeSBR:
+		return "SBR"
+	case ObjectTypePS:
+		return "PS"
+	}
+	return fmt.Sprintf("ObjectType(%d)", uint8(t))
+}
+
+// SampleRates is the samplingFrequencyIndex table.
+var SampleRates = []int{
+	96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050,
+	16000, 12000, 11025, 8000, 7350,
+}
+
+// SampleRateIndex returns the samplingFrequencyIndex of rate, or -1.
+func SampleRateIndex(rate int) int {
+	for i, r := range SampleRates {
+		if r == rate {
+			return i
+		}
+	}
+	return -1
+}
+
+const (
+	_syncExtensionSBR = 0x2b7
+	_syncExtensionPS  = 0x548
+)
+
+// Config is an AudioSpecificConfig. Both the hierarchical (object type 5
+// or 29 first) and the backward compatible signalling of SBR/PS are
+// normalized so that ObjectType and SampleRate always describe the core
+// codec, and ExtensionSampleRate the SBR output rate.
+type Config struct {
+	ObjectType          ObjectType
+	SampleRate          int
+	ChannelConfig       uint8
+	SBR                 bool
+	PS                  bool
+	ExtensionSampleRate int
+	FrameLengthFlag     bool // 960 instead of 1024 samples per frame
+	DependsOnCoreCoder  bool
+	CoreCoderDelay      uint16
+}
+
+// ParseConfig parses an AudioSpecificConfig.
+func ParseConfig(b []byte) (*Config, error) {
+	c := &Config{}
+	if err := c.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Unmarshal parses an AudioSpecificConfig into c.
+func (c *Config) Unmarshal(b []byte) error {
+	*c = Config{}
+	r := bit.NewReader(b)
+	c.ObjectType = readObjectType(r)
+	c.SampleRate = readSampleRate(r)
+	c.ChannelConfig = uint8(r.Read(4))
+
+	if c.ObjectType == ObjectTypeSBR || c.ObjectType == ObjectTypePS {
+		c.SBR = true
+		c.PS = c.ObjectType == ObjectTypePS
+		c.ExtensionSampleRate = readSampleRate(r)
+		c.ObjectType = readObjectType(r)
+		if c.ObjectType == 22 { // ER BSAC
+			r.Skip(4) // extensionChannelConfiguration
+		}
+	}
+
+	switch c.ObjectType {
+	case 1, 2, 3, 4, 6, 7, 17, 19, 20, 21, 22, 23:
+		c.FrameLengthFlag = r.Read(1) == 1
+		c.DependsOnCoreCoder = r.Read(1) == 1
+		if c.DependsOnCoreCoder {
+			c.CoreCoderDelay = uint16(r.Read(14))
+		}
+		r.Skip(1) // extensionFlag
+	default:
+		return fmt.Errorf("aac: unsupported object type %d", c.ObjectType)
+	}
+	if c.SampleRate == 0 {
+		return fmt.Errorf("aac: invalid sampling frequency index")
+	}
+	if err := r.Err(); err != nil {
+		return fmt.Errorf("aac: parse AudioSpecificConfig: %w", err)
+	}
+
+	// backward compatible explicit signalling, reads past the end are
+	// detected through r.EOF since the remaining bit count is not known
+	if !c.SBR && r.Left() > 0 && r.Read(11) == _syncExtensionSBR &&
+		ObjectType(r.Read(5)) == ObjectTypeSBR && r.Read(1) == 1 {
+		rate := readSampleRate(r)
+		if !r.EOF {
+			c.SBR = true
+			c.ExtensionSampleRate = rate
+		}
+		if c.SBR && r.Left() > 0 && r.Read(11) == _syncExtensionPS {
+			ps := r.Read(1) == 1
+			c.PS = ps && !r.EOF
+		}
+	}
+	return nil
+}
+
+// Marshal encodes c, SBR and PS use the hierarchical signalling.
+func (c *Config) Marshal() ([]byte, error) {
+	if c.ObjectType == 0 || c.ObjectType >= 31 {
+		return nil, fmt.Errorf("aac: invalid object type %d", c.ObjectType)
+	}
+	w := bit.NewWriter()
+	switch {
+	case c.PS:
+		w.Write(uint64(ObjectTypePS), 5)
+	case c.SBR:
+		w.Write(uint64(ObjectTypeSBR), 5)
+	default:
+		w.Write(uint64(c.ObjectType), 5)
+	}
+	writeSampleRate(w, c.SampleRate)
+	w.Write(uint64(c.ChannelConfig), 4)
+	if c.SBR || c.PS {
+		rate := c.ExtensionSampleRate
+		if rate == 0 {
+			rate = c.SampleRate * 2
+		}
+		writeSampleRate(w, rate)
+		w.Write(uint64(c.ObjectType), 5)
+	}
+	w.WriteBool(c.FrameLengthFlag)
+	w.WriteBool(c.DependsOnCoreCoder)
+	if c.DependsOnCoreCoder {
+		w.Write(uint64(c.CoreCoderDelay), 14)
+	}
+	w.Write(0, 1) // extensionFlag
+	w.Align()
+	return w.Bytes(), nil
+}
+
+// OutputSampleRate returns the decoded sample rate, which doubles with SBR.
+func (c *Config) OutputSampleRate() int {
+	if c.SBR && c.ExtensionSampleRate != 0 {
+		return c.ExtensionSampleRate
+	}
+	return c.SampleRate
+}
+
+// Channels returns the number of channels of the channel configuration.
+func (c *Config) Channels() int {
+	switch {
+	case c.PS && c.ChannelConfig == 1:
+		return 2
+	case c.ChannelConfig == 7:
+		return 8
+	case c.ChannelConfig < 7:
+		return int(c.ChannelConfig)
+	}
+	return 0
+}
+
+func readObjectType(r *bit.Reader) ObjectType {
+	t := ObjectType(r.Read(5))
+	if t == 31 {
+		t = 32 + ObjectType(r.Read(6))
+	}
+	return t
+}
+
+func readSampleRate(r *bit.Reader) int {
+	idx := r.Read(4)
+	if idx == 0xf {
+		return int(r.Read(24))
+	}
+	if int(idx) < len(SampleRates) {
+		return SampleRates[idx]
+	}
+	return 0
+}
+
+func writeSampleRate(w *bit.Writer, rate int) {
+	if idx := SampleRateIndex(rate); idx >= 0 {
+		w.Write(uint64(idx), 4)
+		return
+	}
+	w.Write(0xf, 4)
+	w.Write(uint64(rate), 24)
+}
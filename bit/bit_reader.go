@@ -11,6 +11,7 @@ type Reader struct {
 	offs   uint32 // 0-7
 	buffer []byte
 	EOF    bool
+	lsb    bool // LSB-first bit order
 }
 
 // NewReader ...
@@ -25,6 +26,20 @@ func NewReader(in []byte) *Reader {
 	return r
 }
 
+// NewReaderLSB returns a Reader taking bits from the least significant
+// bit of each byte first, as Vorbis and Opus headers are packed. Values
+// read at byte boundaries are little-endian.
+func NewReaderLSB(in []byte) *Reader {
+	r := NewReader(in)
+	r.lsb = true
+	return r
+}
+
+// SetLSB switches between MSB-first (default) and LSB-first bit order.
+func (r *Reader) SetLSB(lsb bool) {
+	r.lsb = lsb
+}
+
 // SetPos ...
 func (r *Reader) SetPos(n int) {
 	if n < len(r.buffer)-1 {
@@ -76,6 +91,9 @@ func (r *Reader) ReadUInt(n uint32) uint {
 
 // Read ...
 func (r *Reader) Read(n uint32) uint64 {
+	if r.lsb {
+		return r.readLSB(n)
+	}
 	var d uint32
 	var v uint64
 	for n > 0 {
@@ -102,6 +120,115 @@ func (r *Reader) Read(n uint32) uint64 {
 	return v
 }
 
+func (r *Reader) readLSB(n uint32) uint64 {
+	var d, shift uint32
+	var v uint64
+	for n > 0 {
+		if r.pos >= len(r.buffer) {
+			r.EOF = true
+			return 0
+		}
+		if r.offs+n > 8 {
+			d = 8 - r.offs
+		} else {
+			d = n
+		}
+		v |= uint64((r.buffer[r.pos]>>r.offs)&(0xff>>(8-d))) << shift
+		shift += d
+		r.offs += d
+		n -= d
+
+		if r.offs == 8 {
+			r.pos++
+			r.offs = 0
+		}
+	}
+
+	return v
+}
+
+// Peek returns the next n bits without consuming them. It returns 0 and
+// leaves EOF unchanged if fewer than n bits are left.
+func (r *Reader) Peek(n uint32) uint64 {
+	if int(n) > r.BitsLeft() {
+		return 0
+	}
+	pos, offs := r.pos, r.offs
+	v := r.Read(n)
+	r.pos, r.offs = pos, offs
+	return v
+}
+
+// ReadBool reads one bit.
+func (r *Reader) ReadBool() bool {
+	return r.Read(1) == 1
+}
+
+// ReadUE reads an unsigned Exp-Golomb value, alias of ReadGolomb.
+func (r *Reader) ReadUE() uint64 {
+	return r.ReadGolomb()
+}
+
+// ReadSE reads a signed Exp-Golomb value, alias of ReadSeGolomb.
+func (r *Reader) ReadSE() int {
+	return r.ReadSeGolomb()
+}
+
+// ReadLE16 reads 2 bytes as a little-endian value at any bit position.
+func (r *Reader) ReadLE16() uint16 {
+	return uint16(r.readLE(2))
+}
+
+// ReadLE32 reads 4 bytes as a little-endian value at any bit position.
+func (r *Reader) ReadLE32() uint32 {
+	return uint32(r.readLE(4))
+}
+
+// ReadLE64 reads 8 bytes as a little-endian value at any bit position.
+func (r *Reader) ReadLE64() uint64 {
+	return r.readLE(8)
+}
+
+func (r *Reader) readLE(n int) uint64 {
+	var v uint64
+	for i := 0; i < n; i++ {
+		v |= r.Read(8) << (8 * i)
+	}
+	if r.EOF {
+		return 0
+	}
+	return v
+}
+
+// BitPos returns the position in bits from the start of the buffer.
+func (r *Reader) BitPos() int {
+	return r.pos*8 + int(r.offs)
+}
+
+// BitsLeft returns the number of unread bits.
+func (r *Reader) BitsLeft() int {
+	return len(r.buffer)*8 - r.BitPos()
+}
+
+// SeekBit moves to bit offset from the start of the buffer and clears EOF.
+func (r *Reader) SeekBit(offset int) error {
+	if offset < 0 || offset > len(r.buffer)*8 {
+		return ErrShortBuffer
+	}
+	r.pos = offset / 8
+	r.offs = uint32(offset % 8)
+	r.EOF = false
+	return nil
+}
+
+// ByteAlign skips to the next byte boundary.
+func (r *Reader) ByteAlign() {
+	if r.offs != 0 {
+		r.pos++
+		r.offs = 0
+	}
+}
+
 // Read8 ...
 func (r *Reader) Read8() uint {
 	return uint(r.Read(8))
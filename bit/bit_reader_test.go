@@ -0,0 +1,128 @@
+package bit_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kakami/pkg/bit"
+)
+
+func Test_ReaderPeekSeek(t *testing.T) {
+	r := bit.NewReader([]byte{0xa5, 0x0f, 0xf0})
+	if v := r.Peek(4); v != 0xa {
+		t.Fatalf("peek %x", v)
+	}
+	if r.BitPos() != 0 {
+		t.Fatalf("peek consumed %d bits", r.BitPos())
+	}
+	if v := r.Read(4); v != 0xa {
+		t.Fatalf("read %x", v)
+	}
+	if a, b := r.ReadBool(), r.ReadBool(); a || !b || r.BitPos() != 6 {
+		t.Fatalf("bools at %d", r.BitPos())
+	}
+	r.ByteAlign()
+	if r.BitPos() != 8 || r.Read8() != 0x0f {
+		t.Fatal("byte align")
+	}
+	r.ByteAlign()
+	if r.BitPos() != 16 {
+		t.Fatalf("aligned reader moved to %d", r.BitPos())
+	}
+
+	if v := r.Peek(9); v != 0 || r.EOF {
+		t.Fatalf("peek past end %x %v", v, r.EOF)
+	}
+	if r.BitsLeft() != 8 {
+		t.Fatalf("bits left %d", r.BitsLeft())
+	}
+	r.Read(16)
+	if !r.EOF {
+		t.Fatal("expected EOF")
+	}
+	if err := r.SeekBit(12); err != nil {
+		t.Fatal(err)
+	}
+	if r.EOF || r.Read(8) != 0xff {
+		t.Fatal("seek bit")
+	}
+	if err := r.SeekBit(25); !errors.Is(err, bit.ErrShortBuffer) {
+		t.Fatalf("got %v, want ErrShortBuffer", err)
+	}
+}
+
+func Test_ReaderGolombAliases(t *testing.T) {
+	w := bit.NewWriter()
+	w.WriteGolomb(7)
+	w.WriteSeGolomb(-3)
+	r := bit.NewReader(w.Bytes())
+	if v := r.ReadUE(); v != 7 {
+		t.Fatalf("ue %d", v)
+	}
+	if v := r.ReadSE(); v != -3 {
+		t.Fatalf("se %d", v)
+	}
+}
+
+func Test_ReaderLSB(t *testing.T) {
+	// Vorbis identification header fields after the packet type
+	b := []byte{0x00, 0x00, 0x00, 0x00, 0x02, 0x44, 0xac, 0x00, 0x00, 0xb8}
+	r := bit.NewReaderLSB(b)
+	if v := r.Read(32); v != 0 {
+		t.Fatalf("version %d", v)
+	}
+	if v := r.Read(8); v != 2 {
+		t.Fatalf("channels %d", v)
+	}
+	if v := r.Read(32); v != 44100 {
+		t.Fatalf("rate %d", v)
+	}
+	// blocksize_0 and blocksize_1 share a byte, low nibble first
+	if v := r.Read(4); v != 8 {
+		t.Fatalf("blocksize_0 %d", v)
+	}
+	if v := r.Read(4); v != 11 {
+		t.Fatalf("blocksize_1 %d", v)
+	}
+
+	r = bit.NewReaderLSB([]byte{0xb5})
+	want := []uint64{1, 0, 1, 0, 1, 1, 0, 1}
+	for i, w := range want {
+		if v := r.Read(1); v != w {
+			t.Fatalf("bit %d: %d", i, v)
+		}
+	}
+
+	r = bit.NewReader([]byte{0xb5})
+	r.SetLSB(true)
+	if v := r.Read(3); v != 0x5 {
+		t.Fatalf("lsb 3 bits %x", v)
+	}
+	if v := r.Read(5); v != 0x16 {
+		t.Fatalf("lsb 5 bits %x", v)
+	}
+}
+
+func Test_ReaderLE(t *testing.T) {
+	b := []byte{0x34, 0x12, 0x78, 0x56, 0x34, 0x12, 0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01}
+	r := bit.NewReader(b)
+	if v := r.ReadLE16(); v != 0x1234 {
+		t.Fatalf("le16 %x", v)
+	}
+	if v := r.ReadLE32(); v != 0x12345678 {
+		t.Fatalf("le32 %x", v)
+	}
+	if v := r.ReadLE64(); v != 0x0102030405060708 {
+		t.Fatalf("le64 %x", v)
+	}
+	if v := r.ReadLE16(); v != 0 || !r.EOF {
+		t.Fatalf("le16 past end %x", v)
+	}
+
+	// unaligned
+	r = bit.NewReader([]byte{0x83, 0x41, 0x80})
+	r.Skip(1)
+	if v := r.ReadLE16(); v != 0x8306 {
+		t.Fatalf("unaligned le16 %x", v)
+	}
+}
@@ -0,0 +1,229 @@
+package bit
+
+import (
+	"errors"
+	"io"
+	"math"
+)
+
+const (
+	_defaultStreamBuffer = 4096
+)
+
+// ErrShortBuffer is returned when the input ends in the middle of a value.
+var ErrShortBuffer = errors.New("bit: short buffer")
+
+// Err returns ErrShortBuffer once a read ran past the end of the buffer.
+func (r *Reader) Err() error {
+	if r.EOF {
+		return ErrShortBuffer
+	}
+	return nil
+}
+
+// Seek moves to byte n, unlike SetPos it reports an out of range n.
+func (r *Reader) Seek(n int) error {
+	if n < 0 || n > len(r.buffer) {
+		return ErrShortBuffer
+	}
+	r.pos = n
+	r.offs = 0
+	return nil
+}
+
+// StreamReader reads bits MSB-first from an io.Reader, holding at most
+// one buffer of input in memory. Reads return io.EOF when the input ends
+// before a value starts and ErrShortBuffer when it ends inside one.
+// Errors are sticky.
+type StreamReader struct {
+	rd     io.Reader
+	buffer []byte
+	pos    int
+	offs   uint32 // 0-7
+	base   int64  // stream offset of buffer[0]
+	err    error
+}
+
+// NewStreamReader reads rd through a buffer of size bytes (4096 if size <= 0).
+func NewStreamReader(rd io.Reader, size int) *StreamReader {
+	if size <= 0 {
+		size = _defaultStreamBuffer
+	}
+	return &StreamReader{
+		rd:     rd,
+		buffer: make([]byte, 0, size),
+	}
+}
+
+// fill makes sure at least one byte is buffered.
+func (r *StreamReader) fill() error {
+	if r.pos < len(r.buffer) {
+		return nil
+	}
+	if r.err != nil {
+		return r.err
+	}
+	r.base += int64(len(r.buffer))
+	r.buffer = r.buffer[:0]
+	r.pos = 0
+	for retry := 0; retry < 100; retry++ {
+		n, err := r.rd.Read(r.buffer[:cap(r.buffer)])
+		r.buffer = r.buffer[:n]
+		if err != nil {
+			r.err = err
+		}
+		if n > 0 {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	r.err = io.ErrNoProgress
+	return r.err
+}
+
+func (r *StreamReader) fail(err error, partial bool) error {
+	if err == io.EOF && partial {
+		err = ErrShortBuffer
+	}
+	if err != io.EOF {
+		r.err = err
+	}
+	return err
+}
+
+// Read reads n (at most 64) bits.
+func (r *StreamReader) Read(n uint32) (uint64, error) {
+	var d uint32
+	var v uint64
+	partial := false
+	for n > 0 {
+		if err := r.fill(); err != nil {
+			return 0, r.fail(err, partial)
+		}
+		partial = true
+		if r.offs+n > 8 {
+			d = 8 - r.offs
+		} else {
+			d = n
+		}
+		v = v << d
+		v += (uint64(r.buffer[r.pos] >> (8 - r.offs - d))) & (0xff >> (8 - d))
+		r.offs += d
+		n -= d
+
+		if r.offs == 8 {
+			r.pos++
+			r.offs = 0
+		}
+	}
+
+	return v, nil
+}
+
+// Read8 ...
+func (r *StreamReader) Read8() (uint, error) {
+	v, err := r.Read(8)
+	return uint(v), err
+}
+
+// ReadGolomb ...
+func (r *StreamReader) ReadGolomb() (uint64, error) {
+	var n uint32
+	for ; ; n++ {
+		b, err := r.Read(1)
+		if err != nil {
+			return 0, r.fail(err, n > 0)
+		}
+		if b == 1 {
+			break
+		}
+		if n >= 63 {
+			r.err = ErrShortBuffer
+			return 0, r.err
+		}
+	}
+	v, err := r.Read(n)
+	if err != nil {
+		return 0, r.fail(err, true)
+	}
+	return uint64(1<<n) + v - 1, nil
+}
+
+// ReadSeGolomb ...
+func (r *StreamReader) ReadSeGolomb() (int, error) {
+	v, err := r.ReadGolomb()
+	if err != nil {
+		return 0, err
+	}
+	ueVal := int(v)
+	k := float64(ueVal)
+
+	seVal := int(math.Ceil(k / 2))
+	if ueVal%2 == 0 {
+		seVal = -seVal
+	}
+
+	return seVal, nil
+}
+
+// Skip skips n bits.
+func (r *StreamReader) Skip(n uint64) error {
+	partial := false
+	for n > 0 {
+		if err := r.fill(); err != nil {
+			return r.fail(err, partial)
+		}
+		partial = true
+		if r.offs == 0 && n >= 8 {
+			k := min(uint64(len(r.buffer)-r.pos), n/8)
+			r.pos += int(k)
+			n -= k * 8
+			continue
+		}
+		d := min(uint64(8-r.offs), n)
+		r.offs += uint32(d)
+		n -= d
+		if r.offs == 8 {
+			r.pos++
+			r.offs = 0
+		}
+	}
+	return nil
+}
+
+// ReadBytes reads n bytes into a new slice, it does not need to be byte aligned.
+func (r *StreamReader) ReadBytes(n int) ([]byte, error) {
+	out := make([]byte, 0, n)
+	for len(out) < n {
+		if err := r.fill(); err != nil {
+			return nil, r.fail(err, len(out) > 0)
+		}
+		if r.offs != 0 {
+			b, err := r.Read(8)
+			if err != nil {
+				return nil, r.fail(err, true)
+			}
+			out = append(out, byte(b))
+			continue
+		}
+		k := min(len(r.buffer)-r.pos, n-len(out))
+		out = append(out, r.buffer[r.pos:r.pos+k]...)
+		r.pos += k
+	}
+	return out, nil
+}
+
+// Align skips to the next byte boundary.
+func (r *StreamReader) Align() {
+	if r.offs != 0 {
+		r.pos++
+		r.offs = 0
+	}
+}
+
+// Offset returns the number of whole bytes consumed from the stream.
+func (r *StreamReader) Offset() int64 {
+	return r.base + int64(r.pos)
+}
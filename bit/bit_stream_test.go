@@ -0,0 +1,82 @@
+package bit_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/kakami/pkg/bit"
+)
+
+func Test_StreamReader(t *testing.T) {
+	w := bit.NewWriter()
+	for v := uint64(0); v < 1000; v++ {
+		w.WriteGolomb(v)
+		w.WriteSeGolomb(-int(v))
+		w.Write(v, 13)
+	}
+	w.Align()
+	w.WriteBytes([]byte("tail"))
+
+	r := bit.NewStreamReader(iotest.OneByteReader(bytes.NewReader(w.Bytes())), 16)
+	for v := uint64(0); v < 1000; v++ {
+		if got, err := r.ReadGolomb(); err != nil || got != v {
+			t.Fatalf("ue: got %d, %v, want %d", got, err, v)
+		}
+		if got, err := r.ReadSeGolomb(); err != nil || got != -int(v) {
+			t.Fatalf("se: got %d, %v, want %d", got, err, -int(v))
+		}
+		if got, err := r.Read(13); err != nil || got != v {
+			t.Fatalf("got %d, %v, want %d", got, err, v)
+		}
+	}
+	r.Align()
+	if r.Offset() != int64(len(w.Bytes())-4) {
+		t.Errorf("unexpected offset %d", r.Offset())
+	}
+	if b, err := r.ReadBytes(4); err != nil || string(b) != "tail" {
+		t.Fatalf("got %q, %v", b, err)
+	}
+	if _, err := r.Read(1); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func Test_StreamReaderShort(t *testing.T) {
+	r := bit.NewStreamReader(bytes.NewReader([]byte{0xff, 0x00}), 0)
+	if err := r.Skip(12); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(8); !errors.Is(err, bit.ErrShortBuffer) {
+		t.Fatalf("expected ErrShortBuffer, got %v", err)
+	}
+	if _, err := r.Read(1); !errors.Is(err, bit.ErrShortBuffer) {
+		t.Errorf("error is not sticky: %v", err)
+	}
+
+	// leading zeros without the terminating bit
+	r = bit.NewStreamReader(bytes.NewReader([]byte{0x00}), 0)
+	if _, err := r.ReadGolomb(); !errors.Is(err, bit.ErrShortBuffer) {
+		t.Errorf("expected ErrShortBuffer, got %v", err)
+	}
+	r = bit.NewStreamReader(bytes.NewReader([]byte{0x01}), 0)
+	if _, err := r.ReadBytes(2); !errors.Is(err, bit.ErrShortBuffer) {
+		t.Errorf("expected ErrShortBuffer, got %v", err)
+	}
+}
+
+func Test_ReaderErr(t *testing.T) {
+	r := bit.NewReader([]byte{0x01, 0x02})
+	if err := r.Seek(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Seek(3); !errors.Is(err, bit.ErrShortBuffer) {
+		t.Errorf("expected ErrShortBuffer, got %v", err)
+	}
+	r.Read(1)
+	if !errors.Is(r.Err(), bit.ErrShortBuffer) {
+		t.Errorf("expected ErrShortBuffer, got %v", r.Err())
+	}
+}
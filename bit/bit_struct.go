@@ -0,0 +1,502 @@
+package bit
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type fieldKind int
+
+const (
+	_kindFixed fieldKind = iota
+	_kindUE
+	_kindSE
+)
+
+type condition struct {
+	field int
+	op    string // "", "!", "==", "!="
+	value uint64
+}
+
+type fieldSpec struct {
+	index    int
+	name     string
+	blank    bool
+	kind     fieldKind
+	width    uint32
+	skip     uint32
+	align    bool
+	cond     *condition
+	lenField int // -1 if none
+}
+
+type structSpec struct {
+	fields []fieldSpec
+}
+
+var _specs sync.Map // reflect.Type -> *structSpec
+
+var errNotStruct = errors.New("bit: value must be a struct or a pointer to one")
+
+// fieldError reports the path of the field a value failed on.
+type fieldError struct {
+	path string
+	err  error
+}
+
+func (e *fieldError) Error() string {
+	return "bit: field " + e.path + ": " + e.err.Error()
+}
+
+func (e *fieldError) Unwrap() error {
+	return e.err
+}
+
+func wrapField(name string, err error) error {
+	var fe *fieldError
+	if errors.As(err, &fe) {
+		fe.path = name + "." + fe.path
+		return fe
+	}
+	return &fieldError{path: name, err: err}
+}
+
+// Unmarshal decodes data into the struct pointed to by v. Fields are
+// mapped to bit fields in declaration order; a field is coded only when it
+// has a `bits` tag, use `bits:""` for a nested struct without options. The
+// tag is a comma separated list of
+//
+//	4          fixed width, defaults to the size of the type (1 for bool)
+//	ue, se     unsigned and signed Exp-Golomb code
+//	skip=2     reserved bits before the field
+//	align      skip to the next byte boundary before the field
+//	if=Flag    field present if the earlier field Flag is true or non-zero,
+//	           also if=!Flag, if=Type==3 and if=Type!=3
+//	len=Count  element count of a slice, taken from an earlier field
+//
+// Fixed width signed integers are two's complement. Blank (_) fields are
+// skipped on decoding and written as zeros. Arrays and slices apply the
+// width to each element, so a []byte with len=N reads N bytes.
+func Unmarshal(data []byte, v interface{}) error {
+	return NewReader(data).Decode(v)
+}
+
+// Marshal encodes the struct v, padding the last byte with zeros.
+func Marshal(v interface{}) ([]byte, error) {
+	w := NewWriter()
+	if err := w.Encode(v); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
+
+// Decode reads the struct pointed to by v from the current position.
+func (r *Reader) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errNotStruct
+	}
+	if _, err := specOf(rv.Elem().Type()); err != nil {
+		return wrapField(rv.Elem().Type().Name(), err)
+	}
+	if err := r.decodeStruct(rv.Elem()); err != nil {
+		return wrapField(rv.Elem().Type().Name(), err)
+	}
+	return nil
+}
+
+// Encode writes the struct, or pointer to struct, v.
+func (w *Writer) Encode(v interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return errNotStruct
+	}
+	if _, err := specOf(rv.Type()); err != nil {
+		return wrapField(rv.Type().Name(), err)
+	}
+	if err := w.encodeStruct(rv); err != nil {
+		return wrapField(rv.Type().Name(), err)
+	}
+	return nil
+}
+
+func (r *Reader) decodeStruct(v reflect.Value) error {
+	spec, err := specOf(v.Type())
+	if err != nil {
+		return err
+	}
+	for i := range spec.fields {
+		f := &spec.fields[i]
+		if f.cond != nil && !f.cond.eval(v) {
+			continue
+		}
+		if f.align {
+			r.ByteAlign()
+		}
+		r.Skip(f.skip)
+		if f.blank {
+			r.Skip(f.width)
+		} else if err := r.decodeField(v.Field(f.index), v, f); err != nil {
+			return wrapField(f.name, err)
+		}
+		if r.EOF {
+			return wrapField(f.name, ErrShortBuffer)
+		}
+	}
+	return nil
+}
+
+func (r *Reader) decodeField(fv, sv reflect.Value, f *fieldSpec) error {
+	switch fv.Kind() {
+	case reflect.Array:
+		for i := 0; i < fv.Len() && !r.EOF; i++ {
+			if err := r.decodeValue(fv.Index(i), f); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice:
+		n, _ := intValue(sv.Field(f.lenField))
+		if n > uint64(r.BitsLeft()) { // each element takes at least one bit
+			return ErrShortBuffer
+		}
+		s := reflect.MakeSlice(fv.Type(), int(n), int(n))
+		for i := 0; i < int(n) && !r.EOF; i++ {
+			if err := r.decodeValue(s.Index(i), f); err != nil {
+				return err
+			}
+		}
+		fv.Set(s)
+		return nil
+	}
+	return r.decodeValue(fv, f)
+}
+
+func (r *Reader) decodeValue(v reflect.Value, f *fieldSpec) error {
+	if v.Kind() == reflect.Struct {
+		return r.decodeStruct(v)
+	}
+	var u uint64
+	var s int64
+	switch f.kind {
+	case _kindUE:
+		u = r.ReadGolomb()
+		s = int64(u)
+	case _kindSE:
+		s = int64(r.ReadSeGolomb())
+		u = uint64(s)
+	default:
+		u = r.Read(f.width)
+		s = int64(u)
+		if f.width < 64 && u&(1<<(f.width-1)) != 0 {
+			s -= 1 << f.width
+		}
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(u != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.OverflowInt(s) {
+			return fmt.Errorf("value %d overflows %s", s, v.Type())
+		}
+		v.SetInt(s)
+	default:
+		if f.kind == _kindSE && s < 0 || v.OverflowUint(u) {
+			return fmt.Errorf("value %d overflows %s", s, v.Type())
+		}
+		v.SetUint(u)
+	}
+	return nil
+}
+
+func (w *Writer) encodeStruct(v reflect.Value) error {
+	spec, err := specOf(v.Type())
+	if err != nil {
+		return err
+	}
+	for i := range spec.fields {
+		f := &spec.fields[i]
+		if f.cond != nil && !f.cond.eval(v) {
+			continue
+		}
+		if f.align {
+			w.Align()
+		}
+		w.Write(0, f.skip)
+		if f.blank {
+			w.Write(0, f.width)
+		} else if err := w.encodeField(v.Field(f.index), v, f); err != nil {
+			return wrapField(f.name, err)
+		}
+	}
+	return nil
+}
+
+func (w *Writer) encodeField(fv, sv reflect.Value, f *fieldSpec) error {
+	switch fv.Kind() {
+	case reflect.Slice:
+		if n, _ := intValue(sv.Field(f.lenField)); n != uint64(fv.Len()) {
+			return fmt.Errorf("length %d does not match %s=%d", fv.Len(), sv.Type().Field(f.lenField).Name, n)
+		}
+		fallthrough
+	case reflect.Array:
+		for i := 0; i < fv.Len(); i++ {
+			if err := w.encodeValue(fv.Index(i), f); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return w.encodeValue(fv, f)
+}
+
+func (w *Writer) encodeValue(v reflect.Value, f *fieldSpec) error {
+	if v.Kind() == reflect.Struct {
+		return w.encodeStruct(v)
+	}
+	var u uint64
+	var s int64
+	signed := false
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			u, s = 1, 1
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = v.Int()
+		u = uint64(s)
+		signed = true
+	default:
+		u = v.Uint()
+		s = int64(u)
+	}
+	switch f.kind {
+	case _kindUE:
+		if signed && s < 0 || u == 1<<64-1 {
+			return fmt.Errorf("value %d out of ue range", s)
+		}
+		w.WriteGolomb(u)
+	case _kindSE:
+		w.WriteSeGolomb(int(s))
+	default:
+		if f.width < 64 {
+			if signed && (s < -1<<(f.width-1) || s >= 1<<(f.width-1)) || !signed && u >= 1<<f.width {
+				return fmt.Errorf("value %d does not fit in %d bits", s, f.width)
+			}
+		}
+		w.Write(u, f.width)
+	}
+	return nil
+}
+
+func (c *condition) eval(v reflect.Value) bool {
+	x, _ := intValue(v.Field(c.field))
+	switch c.op {
+	case "!":
+		return x == 0
+	case "==":
+		return x == c.value
+	case "!=":
+		return x != c.value
+	}
+	return x != 0
+}
+
+// intValue returns bool and integer fields as uint64.
+func intValue(v reflect.Value) (uint64, bool) {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return 1, true
+		}
+		return 0, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint(), true
+	}
+	return 0, false
+}
+
+func specOf(t reflect.Type) (*structSpec, error) {
+	return specIn(t, make(map[reflect.Type]bool))
+}
+
+// specIn returns the spec of t, building holds the types being built
+// further up, a struct containing itself through a slice or array.
+func specIn(t reflect.Type, building map[reflect.Type]bool) (*structSpec, error) {
+	if s, ok := _specs.Load(t); ok {
+		return s.(*structSpec), nil
+	}
+	if building[t] {
+		return nil, fmt.Errorf("recursive type %s", t)
+	}
+	building[t] = true
+	defer delete(building, t)
+	s, err := buildSpec(t, building)
+	if err != nil {
+		return nil, err
+	}
+	_specs.Store(t, s)
+	return s, nil
+}
+
+func buildSpec(t reflect.Type, building map[reflect.Type]bool) (*structSpec, error) {
+	s := &structSpec{}
+	names := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, tagged := sf.Tag.Lookup("bits")
+		if tag == "-" || !tagged {
+			names[sf.Name] = i
+			continue
+		}
+		f, err := parseTag(sf, i, tag, names, t, building)
+		if err != nil {
+			return nil, wrapField(sf.Name, err)
+		}
+		s.fields = append(s.fields, f)
+		names[sf.Name] = i
+	}
+	return s, nil
+}
+
+func parseTag(sf reflect.StructField, index int, tag string, names map[string]int, t reflect.Type,
+	building map[reflect.Type]bool) (fieldSpec, error) {
+	f := fieldSpec{index: index, name: sf.Name, blank: sf.Name == "_", lenField: -1}
+	if !f.blank && sf.PkgPath != "" {
+		return f, errors.New("unexported field")
+	}
+	hasWidth := false
+	for _, opt := range strings.Split(tag, ",") {
+		opt = strings.TrimSpace(opt)
+		key, val, _ := strings.Cut(opt, "=")
+		switch {
+		case opt == "":
+		case opt == "ue":
+			f.kind = _kindUE
+		case opt == "se":
+			f.kind = _kindSE
+		case opt == "align":
+			f.align = true
+		case key == "skip":
+			n, err := strconv.ParseUint(val, 10, 32)
+			if err != nil {
+				return f, fmt.Errorf("invalid skip %q", val)
+			}
+			f.skip = uint32(n)
+		case key == "len":
+			j, ok := names[val]
+			if !ok {
+				return f, fmt.Errorf("len field %q not declared before", val)
+			}
+			if _, ok := intValue(reflect.Zero(t.Field(j).Type)); !ok {
+				return f, fmt.Errorf("len field %q is not an integer", val)
+			}
+			f.lenField = j
+		case key == "if":
+			c, err := parseCondition(opt[len("if="):], names, t)
+			if err != nil {
+				return f, err
+			}
+			f.cond = c
+		default:
+			n, err := strconv.ParseUint(opt, 10, 32)
+			if err != nil || n == 0 || n > 64 {
+				return f, fmt.Errorf("invalid option %q", opt)
+			}
+			f.width = uint32(n)
+			hasWidth = true
+		}
+	}
+	if f.blank {
+		if f.kind != _kindFixed {
+			return f, errors.New("blank field must have a fixed width")
+		}
+		return f, nil
+	}
+
+	et := sf.Type
+	switch et.Kind() {
+	case reflect.Slice:
+		if f.lenField < 0 {
+			return f, errors.New("slice needs a len option")
+		}
+		et = et.Elem()
+	case reflect.Array:
+		et = et.Elem()
+	}
+	if et.Kind() == reflect.Struct {
+		if hasWidth || f.kind != _kindFixed {
+			return f, errors.New("width on struct field")
+		}
+		_, err := specIn(et, building)
+		return f, err
+	}
+	size, ok := bitSize(et)
+	if !ok {
+		return f, fmt.Errorf("unsupported type %s", sf.Type)
+	}
+	if f.kind == _kindFixed {
+		if !hasWidth {
+			f.width = size
+		}
+		if f.width > size {
+			return f, fmt.Errorf("width %d exceeds %s", f.width, et)
+		}
+	} else if et.Kind() == reflect.Bool {
+		return f, errors.New("Exp-Golomb code on bool")
+	}
+	return f, nil
+}
+
+func parseCondition(expr string, names map[string]int, t reflect.Type) (*condition, error) {
+	c := &condition{}
+	name := expr
+	switch {
+	case strings.HasPrefix(expr, "!"):
+		c.op, name = "!", expr[1:]
+	case strings.Contains(expr, "=="), strings.Contains(expr, "!="):
+		op := "=="
+		if strings.Contains(expr, "!=") {
+			op = "!="
+		}
+		var val string
+		name, val, _ = strings.Cut(expr, op)
+		n, err := strconv.ParseUint(val, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition %q", expr)
+		}
+		c.op, c.value = op, n
+	}
+	j, ok := names[name]
+	if !ok {
+		return nil, fmt.Errorf("condition field %q not declared before", name)
+	}
+	if _, ok := intValue(reflect.Zero(t.Field(j).Type)); !ok {
+		return nil, fmt.Errorf("condition field %q is not a bool or integer", name)
+	}
+	c.field = j
+	return c, nil
+}
+
+func bitSize(t reflect.Type) (uint32, bool) {
+	switch t.Kind() {
+	case reflect.Bool:
+		return 1, true
+	case reflect.Int8, reflect.Uint8:
+		return 8, true
+	case reflect.Int16, reflect.Uint16:
+		return 16, true
+	case reflect.Int32, reflect.Uint32:
+		return 32, true
+	case reflect.Int, reflect.Uint, reflect.Int64, reflect.Uint64:
+		return 64, true
+	}
+	return 0, false
+}
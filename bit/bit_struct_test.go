@@ -0,0 +1,195 @@
+package bit_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kakami/pkg/bit"
+)
+
+type adtsFixed struct {
+	Sync             uint16 `bits:"12"`
+	MPEG2            bool   `bits:"1"`
+	_                uint8  `bits:"2"`
+	ProtectionAbsent bool   `bits:"1"`
+	Profile          uint8  `bits:"2"`
+	SampleRateIndex  uint8  `bits:"4"`
+	ChannelConfig    uint8  `bits:"3,skip=1"`
+	FrameLength      uint16 `bits:"13,skip=4"`
+	BufferFullness   uint16 `bits:"11"`
+	RawDataBlocks    uint8  `bits:"2"`
+	CRC              uint16 `bits:"16,if=!ProtectionAbsent"`
+	Ignored          int
+}
+
+type timing struct {
+	NumUnitsInTick uint32 `bits:"32"`
+	TimeScale      uint32 `bits:"32"`
+}
+
+type header struct {
+	ID         uint64  `bits:"ue"`
+	Offset     int     `bits:"se"`
+	Delta      int8    `bits:"4"`
+	HasTiming  bool    `bits:"1"`
+	Timing     timing  `bits:"if=HasTiming"`
+	Mode       uint8   `bits:"2"`
+	Extra      uint8   `bits:"6,if=Mode==2"`
+	Other      uint8   `bits:"3,if=Mode!=2"`
+	Count      uint8   `bits:"ue"`
+	Levels     []int8  `bits:"se,len=Count"`
+	Name       [3]byte `bits:"8"`
+	Size       uint8   `bits:"8,align"`
+	Payload    []byte  `bits:"len=Size"`
+	unexported int
+}
+
+func Test_UnmarshalADTS(t *testing.T) {
+	data := []byte{0xff, 0xf1, 0x50, 0x80, 0x2e, 0x7f, 0xfc}
+	var h adtsFixed
+	if err := bit.Unmarshal(data, &h); err != nil {
+		t.Fatal(err)
+	}
+	want := adtsFixed{
+		Sync:             0xfff,
+		ProtectionAbsent: true,
+		Profile:          1,
+		SampleRateIndex:  4,
+		ChannelConfig:    2,
+		FrameLength:      371,
+		BufferFullness:   0x7ff,
+	}
+	if h != want {
+		t.Fatalf("got %+v, want %+v", h, want)
+	}
+	b, err := bit.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, data) {
+		t.Fatalf("marshal %x, want %x", b, data)
+	}
+
+	// CRC present
+	data[1] = 0xf0
+	data = append(data, 0xbe, 0xef)
+	if err := bit.Unmarshal(data, &h); err != nil {
+		t.Fatal(err)
+	}
+	if h.ProtectionAbsent || h.CRC != 0xbeef {
+		t.Fatalf("got %+v", h)
+	}
+	if err := bit.Unmarshal(data[:7], &h); !errors.Is(err, bit.ErrShortBuffer) {
+		t.Fatalf("got %v, want ErrShortBuffer", err)
+	}
+}
+
+func Test_MarshalRoundTrip(t *testing.T) {
+	cases := []header{
+		{ID: 5, Offset: -3, Delta: -8, HasTiming: true, Timing: timing{1001, 60000}, Mode: 2, Extra: 63,
+			Count: 3, Levels: []int8{-1, 0, 7}, Name: [3]byte{'a', 'b', 'c'}, Size: 2, Payload: []byte{1, 2}},
+		{ID: 0, Offset: 4, Delta: 7, Mode: 1, Other: 5, Levels: []int8{}, Payload: []byte{}},
+	}
+	for i, c := range cases {
+		b, err := bit.Marshal(&c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got header
+		if err := bit.Unmarshal(b, &got); err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if got.ID != c.ID || got.Offset != c.Offset || got.Delta != c.Delta || got.Timing != c.Timing ||
+			got.Mode != c.Mode || got.Extra != c.Extra || got.Other != c.Other || got.Name != c.Name ||
+			!bytes.Equal(got.Payload, c.Payload) || len(got.Levels) != len(c.Levels) {
+			t.Fatalf("case %d: got %+v, want %+v", i, got, c)
+		}
+		for j := range c.Levels {
+			if got.Levels[j] != c.Levels[j] {
+				t.Fatalf("case %d: levels %v", i, got.Levels)
+			}
+		}
+	}
+
+	// the decoder continues from the reader position
+	w := bit.NewWriter()
+	w.Write(0x5, 3)
+	if err := w.Encode(timing{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	r := bit.NewReader(w.Bytes())
+	r.Skip(3)
+	var tm timing
+	if err := r.Decode(&tm); err != nil || tm != (timing{1, 2}) {
+		t.Fatalf("got %+v %v", tm, err)
+	}
+}
+
+func Test_UnmarshalUntagged(t *testing.T) {
+	var v struct {
+		A      uint8 `bits:"8"`
+		At     time.Time
+		Timing timing
+		Nested timing `bits:""`
+	}
+	data := []byte{7, 0, 0, 0, 1, 0, 0, 0, 2}
+	if err := bit.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != 7 || v.Timing != (timing{}) || v.Nested != (timing{1, 2}) {
+		t.Fatalf("got %+v", v)
+	}
+}
+
+type node struct {
+	N    uint8  `bits:"8"`
+	Kids []node `bits:"len=N"`
+}
+
+func Test_MarshalErrors(t *testing.T) {
+	if _, err := bit.Marshal(adtsFixed{Sync: 0x1fff}); err == nil || !strings.Contains(err.Error(), "adtsFixed.Sync") {
+		t.Fatalf("got %v", err)
+	}
+	h := header{Count: 2, Levels: []int8{1}}
+	if _, err := bit.Marshal(h); err == nil {
+		t.Fatal("expected length mismatch error")
+	}
+
+	var bad struct {
+		A []byte `bits:"8"`
+	}
+	if err := bit.Unmarshal([]byte{1}, &bad); err == nil || !strings.Contains(err.Error(), "len") {
+		t.Fatalf("got %v", err)
+	}
+	var wide struct {
+		A uint8 `bits:"9"`
+	}
+	if err := bit.Unmarshal([]byte{1, 2}, &wide); err == nil {
+		t.Fatal("expected width error")
+	}
+	var order struct {
+		A uint8 `bits:"8,if=B"`
+		B bool
+	}
+	if err := bit.Unmarshal([]byte{1, 2}, &order); err == nil {
+		t.Fatal("expected condition error")
+	}
+	var overflow struct {
+		A uint8 `bits:"ue"`
+	}
+	w := bit.NewWriter()
+	w.WriteGolomb(300)
+	if err := bit.Unmarshal(w.Bytes(), &overflow); err == nil {
+		t.Fatal("expected overflow error")
+	}
+	if err := bit.Unmarshal(nil, overflow); err == nil {
+		t.Fatal("expected error for non pointer")
+	}
+	var n node
+	if err := bit.Unmarshal([]byte{0}, &n); err == nil || !strings.Contains(err.Error(), "recursive") {
+		t.Fatalf("got %v", err)
+	}
+}
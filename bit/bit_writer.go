@@ -0,0 +1,114 @@
+package bit
+
+import (
+	"math/bits"
+)
+
+// Writer packs values MSB-first, the counterpart of Reader.
+type Writer struct {
+	offs   uint32 // bits used in the last byte, 0-7
+	buffer []byte
+}
+
+// NewWriter ...
+func NewWriter() *Writer {
+	return &Writer{}
+}
+
+// Write writes the n (at most 64) low bits of v.
+func (w *Writer) Write(v uint64, n uint32) {
+	var d uint32
+	for n > 0 {
+		if w.offs == 0 {
+			w.buffer = append(w.buffer, 0)
+		}
+		if w.offs+n > 8 {
+			d = 8 - w.offs
+		} else {
+			d = n
+		}
+		b := byte(v>>(n-d)) & (0xff >> (8 - d))
+		w.buffer[len(w.buffer)-1] |= b << (8 - w.offs - d)
+		w.offs += d
+		n -= d
+
+		if w.offs == 8 {
+			w.offs = 0
+		}
+	}
+}
+
+// Write8 ...
+func (w *Writer) Write8(v uint) {
+	w.Write(uint64(v), 8)
+}
+
+// WriteBool writes a single bit.
+func (w *Writer) WriteBool(b bool) {
+	if b {
+		w.Write(1, 1)
+	} else {
+		w.Write(0, 1)
+	}
+}
+
+// WriteGolomb writes v as unsigned Exp-Golomb code, ue(v).
+// v must be less than math.MaxUint64.
+func (w *Writer) WriteGolomb(v uint64) {
+	x := v + 1
+	n := uint32(bits.Len64(x)) - 1
+	w.Write(0, n)
+	w.Write(x, n+1)
+}
+
+// WriteSeGolomb writes v as signed Exp-Golomb code, se(v).
+func (w *Writer) WriteSeGolomb(v int) {
+	if v > 0 {
+		w.WriteGolomb(uint64(v)*2 - 1)
+	} else {
+		w.WriteGolomb(uint64(-int64(v)) * 2)
+	}
+}
+
+// WriteBytes writes p, it does not need to be byte aligned.
+func (w *Writer) WriteBytes(p []byte) {
+	if w.offs == 0 {
+		w.buffer = append(w.buffer, p...)
+		return
+	}
+	for _, b := range p {
+		w.Write(uint64(b), 8)
+	}
+}
+
+// Align pads with zero bits up to the next byte boundary.
+func (w *Writer) Align() {
+	if w.offs != 0 {
+		w.Write(0, 8-w.offs)
+	}
+}
+
+// Aligned reports whether the writer is at a byte boundary.
+func (w *Writer) Aligned() bool {
+	return w.offs == 0
+}
+
+// BitLen returns the number of bits written.
+func (w *Writer) BitLen() int {
+	if w.offs == 0 {
+		return len(w.buffer) * 8
+	}
+	return (len(w.buffer)-1)*8 + int(w.offs)
+}
+
+// Bytes returns the written bytes, a trailing partial byte is zero padded.
+// The slice is only valid until the next write.
+func (w *Writer) Bytes() []byte {
+	return w.buffer
+}
+
+// Reset ...
+func (w *Writer) Reset() {
+	w.buffer = w.buffer[:0]
+	w.offs = 0
+}
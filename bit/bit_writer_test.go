@@ -0,0 +1,89 @@
+package bit_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kakami/pkg/bit"
+)
+
+func Test_WriterBits(t *testing.T) {
+	w := bit.NewWriter()
+	w.Write(0x5, 3)
+	w.Write(0x1, 1)
+	w.Write(0xabc, 12)
+	w.WriteBool(true)
+	if w.BitLen() != 17 || w.Aligned() {
+		t.Fatalf("unexpected bit length %d", w.BitLen())
+	}
+	w.Align()
+	w.WriteBytes([]byte{0xde, 0xad})
+	want := []byte{0xba, 0xbc, 0x80, 0xde, 0xad}
+	if !bytes.Equal(w.Bytes(), want) {
+		t.Errorf("got %x, want %x", w.Bytes(), want)
+	}
+}
+
+func Test_WriterGolomb(t *testing.T) {
+	// ue(v) codes from ITU-T H.264 table 9-2
+	cases := []struct {
+		v    uint64
+		bits string
+	}{
+		{0, "1"},
+		{1, "010"},
+		{2, "011"},
+		{3, "00100"},
+		{6, "00111"},
+		{7, "0001000"},
+	}
+	for _, c := range cases {
+		w := bit.NewWriter()
+		w.WriteGolomb(c.v)
+		if w.BitLen() != len(c.bits) {
+			t.Errorf("ue(%d): got %d bits, want %s", c.v, w.BitLen(), c.bits)
+			continue
+		}
+		r := bit.NewReader(w.Bytes())
+		for i := range c.bits {
+			if got := r.Read(1); got != uint64(c.bits[i]-'0') {
+				t.Errorf("ue(%d): bit %d is %d, want %s", c.v, i, got, c.bits)
+				break
+			}
+		}
+	}
+}
+
+func Test_WriterRoundTrip(t *testing.T) {
+	w := bit.NewWriter()
+	w.Write(0x1f, 5)
+	for v := uint64(0); v < 300; v++ {
+		w.WriteGolomb(v)
+	}
+	for v := -150; v <= 150; v++ {
+		w.WriteSeGolomb(v)
+	}
+	w.Write(0xffffffffffffffff, 64)
+	w.Write8(0x42)
+
+	r := bit.NewReader(w.Bytes())
+	if got := r.Read(5); got != 0x1f {
+		t.Fatalf("got %x", got)
+	}
+	for v := uint64(0); v < 300; v++ {
+		if got := r.ReadGolomb(); got != v {
+			t.Fatalf("ue: got %d, want %d", got, v)
+		}
+	}
+	for v := -150; v <= 150; v++ {
+		if got := r.ReadSeGolomb(); got != v {
+			t.Fatalf("se: got %d, want %d", got, v)
+		}
+	}
+	if got := r.Read(64); got != 0xffffffffffffffff {
+		t.Fatalf("got %x", got)
+	}
+	if got := r.Read8(); got != 0x42 || r.EOF {
+		t.Fatalf("got %x", got)
+	}
+}
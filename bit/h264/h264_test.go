@@ -0,0 +1,176 @@
+package h264_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kakami/pkg/bit"
+	"github.com/kakami/pkg/bit/h264"
+)
+
+var (
+	// x264 1920x1080 High@L4 30fps
+	_spsHigh1080 = []byte{
+		0x67, 0x64, 0x00, 0x28, 0xac, 0xd9, 0x40, 0x78,
+		0x02, 0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00,
+		0x04, 0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60,
+		0xc6, 0x58,
+	}
+	// 1920x1080 Constrained Baseline@L4 30fps
+	_spsBaseline1080 = []byte{
+		0x67, 0x42, 0xc0, 0x28, 0xd9, 0x00, 0x78, 0x02,
+		0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00, 0x04,
+		0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc9,
+		0x20,
+	}
+	// 1280x720 High@L3.1 30fps
+	_spsHigh720 = []byte{
+		0x67, 0x64, 0x00, 0x1f, 0xac, 0xd9, 0x40, 0x50,
+		0x05, 0xbb, 0x01, 0x10, 0x00, 0x00, 0x03, 0x00,
+		0x10, 0x00, 0x00, 0x03, 0x03, 0xc0, 0xf1, 0x83,
+		0x19, 0x60,
+	}
+	// 352x288 High@L1.2 15fps
+	_spsCIF = []byte{
+		0x67, 0x64, 0x00, 0x0c, 0xac, 0x3b, 0x50, 0xb0,
+		0x4b, 0x42, 0x00, 0x00, 0x03, 0x00, 0x02, 0x00,
+		0x00, 0x03, 0x00, 0x3d, 0x08,
+	}
+	// 1280x720 Main@L3.1 24fps with colour description
+	_spsMain720 = []byte{
+		0x67, 0x4d, 0x40, 0x1f, 0xe8, 0x80, 0x28, 0x02,
+		0xdd, 0x80, 0xb5, 0x01, 0x01, 0x01, 0x40, 0x00,
+		0x00, 0x03, 0x00, 0x40, 0x00, 0x00, 0x0c, 0x03,
+		0xc6, 0x0c, 0x44, 0x80,
+	}
+	_ppsBaseline = []byte{0x68, 0xce, 0x3c, 0x80}
+	_ppsHigh     = []byte{0x68, 0xeb, 0xe3, 0xcb, 0x22, 0xc0}
+)
+
+func Test_ParseSPS(t *testing.T) {
+	cases := []struct {
+		nalu          []byte
+		profile       string
+		level         uint8
+		width, height int
+		fps           float64
+	}{
+		{_spsHigh1080, "High", 40, 1920, 1080, 30},
+		{_spsBaseline1080, "Constrained Baseline", 40, 1920, 1080, 30},
+		{_spsHigh720, "High", 31, 1280, 720, 30},
+		{_spsCIF, "High", 12, 352, 288, 15},
+		{_spsMain720, "Main", 31, 1280, 720, 24},
+	}
+	for _, c := range cases {
+		s, err := h264.ParseSPS(c.nalu)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.ProfileName() != c.profile || s.LevelIDC != c.level {
+			t.Errorf("got %s@%d, want %s@%d", s.ProfileName(), s.LevelIDC, c.profile, c.level)
+		}
+		if s.Width() != c.width || s.Height() != c.height {
+			t.Errorf("got %dx%d, want %dx%d", s.Width(), s.Height(), c.width, c.height)
+		}
+		if s.FrameRate() != c.fps {
+			t.Errorf("got %v fps, want %v", s.FrameRate(), c.fps)
+		}
+	}
+
+	s, _ := h264.ParseSPS(_spsMain720)
+	v := s.VUI
+	if v.AspectRatioIDC != 1 || v.VideoFormat != 5 || !v.ColourDescriptionPresent ||
+		v.ColourPrimaries != 1 || v.MaxNumReorderFrames != 1 || v.MaxDecFrameBuffering != 3 {
+		t.Errorf("unexpected vui %+v", v)
+	}
+
+	if _, err := h264.ParseSPS(_spsHigh1080[:8]); err == nil {
+		t.Error("expected error for truncated SPS")
+	}
+	if _, err := h264.ParseSPS(_ppsHigh); err == nil {
+		t.Error("expected error for PPS")
+	}
+}
+
+func Test_ParsePPS(t *testing.T) {
+	p, err := h264.ParsePPS(_ppsBaseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.EntropyCodingModeFlag || p.PicInitQP != 26 || !p.DeblockingFilterControlPresent {
+		t.Errorf("unexpected pps %+v", p)
+	}
+
+	p, err = h264.ParsePPS(_ppsHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.EntropyCodingModeFlag || p.NumRefIdxL0DefaultActive != 3 || !p.WeightedPred ||
+		p.WeightedBipredIDC != 2 || p.PicInitQP != 23 || p.ChromaQPIndexOffset != -2 {
+		t.Errorf("unexpected pps %+v", p)
+	}
+}
+
+func Test_ParsePPSSliceGroups(t *testing.T) {
+	w := bit.NewWriter()
+	w.Write8(0x68)
+	w.WriteGolomb(0) // pic_parameter_set_id
+	w.WriteGolomb(0) // seq_parameter_set_id
+	w.Write(0, 2)
+	w.WriteGolomb(0x80000000) // num_slice_groups_minus1
+	w.WriteGolomb(6)          // slice_group_map_type
+	w.WriteGolomb(0)
+	w.Write(1, 1)
+	w.Align()
+	if _, err := h264.ParsePPS(w.Bytes()); err == nil {
+		t.Error("expected num_slice_groups_minus1 out of range")
+	}
+}
+
+func Test_SplitNALUs(t *testing.T) {
+	stream := []byte{0xff}
+	stream = append(stream, 0, 0, 0, 1)
+	stream = append(stream, _spsHigh1080...)
+	stream = append(stream, 0, 0, 1)
+	stream = append(stream, _ppsHigh...)
+	stream = append(stream, 0, 0, 0, 1, 0x65, 0x88, 0x84, 0x00, 0x00)
+
+	nalus := h264.SplitNALUs(stream)
+	if len(nalus) != 3 {
+		t.Fatalf("got %d nalus", len(nalus))
+	}
+	if !bytes.Equal(nalus[0], _spsHigh1080) || !bytes.Equal(nalus[1], _ppsHigh) {
+		t.Errorf("unexpected nalus %x", nalus)
+	}
+	types := []h264.NALUType{h264.NALUTypeSPS, h264.NALUTypePPS, h264.NALUTypeIDR}
+	for i, nalu := range nalus {
+		if h264.TypeOf(nalu) != types[i] {
+			t.Errorf("nalu %d: got %s, want %s", i, h264.TypeOf(nalu), types[i])
+		}
+	}
+	if !bytes.Equal(nalus[2], []byte{0x65, 0x88, 0x84}) {
+		t.Errorf("trailing zeros not dropped: %x", nalus[2])
+	}
+
+	joined := h264.JoinNALUs(nalus)
+	if got := h264.SplitNALUs(joined); len(got) != 3 || !bytes.Equal(got[1], _ppsHigh) {
+		t.Errorf("join round trip failed: %x", got)
+	}
+	if got := h264.SplitNALUs(_ppsHigh); len(got) != 1 || !bytes.Equal(got[0], _ppsHigh) {
+		t.Errorf("raw nalu not returned: %x", got)
+	}
+}
+
+func Test_EmulationPrevention(t *testing.T) {
+	ebsp := []byte{0x00, 0x00, 0x03, 0x01, 0x00, 0x00, 0x03, 0x00, 0x00, 0x03}
+	rbsp := []byte{0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}
+	if got := h264.RemoveEmulationPrevention(ebsp); !bytes.Equal(got, rbsp) {
+		t.Errorf("got %x, want %x", got, rbsp)
+	}
+	if got := h264.RemoveEmulationPrevention(h264.AddEmulationPrevention(rbsp)); !bytes.Equal(got, rbsp) {
+		t.Errorf("round trip got %x, want %x", got, rbsp)
+	}
+	if got := h264.AddEmulationPrevention([]byte{0, 0, 1}); !bytes.Equal(got, []byte{0, 0, 3, 1}) {
+		t.Errorf("got %x", got)
+	}
+}
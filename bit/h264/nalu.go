@@ -0,0 +1,155 @@
+// Package h264 parses H.264/AVC Annex-B streams and parameter sets.
+package h264
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// NALUType ...
+type NALUType uint8
+
+// NAL unit types, ITU-T H.264 table 7-1
+const (
+	NALUTypeNonIDR      NALUType = 1
+	NALUTypeDataPartA   NALUType = 2
+	NALUTypeDataPartB   NALUType = 3
+	NALUTypeDataPartC   NALUType = 4
+	NALUTypeIDR         NALUType = 5
+	NALUTypeSEI         NALUType = 6
+	NALUTypeSPS         NALUType = 7
+	NALUTypePPS         NALUType = 8
+	NALUTypeAUD         NALUType = 9
+	NALUTypeEndOfSeq    NALUType = 10
+	NALUTypeEndOfStream NALUType = 11
+	NALUTypeFillerData  NALUType = 12
+	NALUTypeSPSExt      NALUType = 13
+	NALUTypePrefix      NALUType = 14
+	NALUTypeSubsetSPS   NALUType = 15
+	NALUTypeSliceExt    NALUType = 20
+)
+
+var _naluTypeNames = map[NALUType]string{
+	NALUTypeNonIDR:      "NonIDR",
+	NALUTypeDataPartA:   "DataPartA",
+	NALUTypeDataPartB:   "DataPartB",
+	NALUTypeDataPartC:   "DataPartC",
+	NALUTypeIDR:         "IDR",
+	NALUTypeSEI:         "SEI",
+	NALUTypeSPS:         "SPS",
+	NALUTypePPS:         "PPS",
+	NALUTypeAUD:         "AUD",
+	NALUTypeEndOfSeq:    "EndOfSeq",
+	NALUTypeEndOfStream: "EndOfStream",
+	NALUTypeFillerData:  "FillerData",
+	NALUTypeSPSExt:      "SPSExt",
+	NALUTypePrefix:      "Prefix",
+	NALUTypeSubsetSPS:   "SubsetSPS",
+	NALUTypeSliceExt:    "SliceExt",
+}
+
+func (t NALUType) String() string {
+	if s, ok := _naluTypeNames[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("NALUType(%d)", uint8(t))
+}
+
+// TypeOf returns the type of a NAL unit, nalu must not be empty.
+func TypeOf(nalu []byte) NALUType {
+	return NALUType(nalu[0] & 0x1f)
+}
+
+// SplitNALUs splits an Annex-B byte stream at its 3 or 4 byte start codes.
+// Trailing zero bytes of each NAL unit and anything before the first start
+// code are dropped. Data without any start code is returned as a single
+// NAL unit.
+func SplitNALUs(data []byte) [][]byte {
+	var nalus [][]byte
+	start := -1
+	for i := 0; i+2 < len(data); {
+		if data[i] != 0 || data[i+1] != 0 {
+			i++
+			continue
+		}
+		if data[i+2] != 1 {
+			i++
+			continue
+		}
+		if start >= 0 {
+			nalus = appendNALU(nalus, data[start:i])
+		}
+		i += 3
+		start = i
+	}
+	if start < 0 {
+		return appendNALU(nalus, data)
+	}
+	return appendNALU(nalus, data[start:])
+}
+
+func appendNALU(nalus [][]byte, nalu []byte) [][]byte {
+	nalu = bytes.TrimRight(nalu, "\x00")
+	if len(nalu) == 0 {
+		return nalus
+	}
+	return append(nalus, nalu)
+}
+
+// RemoveEmulationPrevention converts a NAL unit payload (EBSP) into its
+// RBSP by dropping every 0x03 that follows two zero bytes. data is not
+// modified; it is returned as is if there is nothing to remove.
+func RemoveEmulationPrevention(data []byte) []byte {
+	idx := bytes.Index(data, []byte{0, 0, 3})
+	if idx < 0 {
+		return data
+	}
+	out := make([]byte, 0, len(data))
+	zeros := 0
+	for _, b := range data {
+		if zeros >= 2 && b == 3 {
+			zeros = 0
+			continue
+		}
+		out = append(out, b)
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	return out
+}
+
+// AddEmulationPrevention is the inverse of RemoveEmulationPrevention.
+func AddEmulationPrevention(data []byte) []byte {
+	out := make([]byte, 0, len(data)+len(data)/64)
+	zeros := 0
+	for _, b := range data {
+		if zeros >= 2 && b <= 3 {
+			out = append(out, 3)
+			zeros = 0
+		}
+		out = append(out, b)
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	return out
+}
+
+// JoinNALUs builds an Annex-B byte stream with 4 byte start codes.
+func JoinNALUs(nalus [][]byte) []byte {
+	n := 0
+	for _, nalu := range nalus {
+		n += 4 + len(nalu)
+	}
+	out := make([]byte, 0, n)
+	for _, nalu := range nalus {
+		out = append(out, 0, 0, 0, 1)
+		out = append(out, nalu...)
+	}
+	return out
+}
@@ -0,0 +1,90 @@
+package h264
+
+import (
+	"fmt"
+
+	"github.com/kakami/pkg/bit"
+)
+
+// PPS is a picture parameter set, ITU-T H.264 7.3.2.2. Fields after
+// RedundantPicCntPresent are not parsed.
+type PPS struct {
+	ID                                uint32
+	SPSID                             uint32
+	EntropyCodingModeFlag             bool
+	BottomFieldPicOrderInFramePresent bool
+	NumSliceGroups                    uint32
+	NumRefIdxL0DefaultActive          uint32
+	NumRefIdxL1DefaultActive          uint32
+	WeightedPred                      bool
+	WeightedBipredIDC                 uint8
+	PicInitQP                         int
+	PicInitQS                         int
+	ChromaQPIndexOffset               int
+	DeblockingFilterControlPresent    bool
+	ConstrainedIntraPred              bool
+	RedundantPicCntPresent            bool
+}
+
+// ParsePPS parses a PPS NAL unit including its header byte.
+func ParsePPS(nalu []byte) (*PPS, error) {
+	if len(nalu) < 2 || TypeOf(nalu) != NALUTypePPS {
+		return nil, fmt.Errorf("h264: not a PPS")
+	}
+	r := bit.NewReader(RemoveEmulationPrevention(nalu[1:]))
+	p := &PPS{
+		ID:                    uint32(r.ReadGolomb()),
+		SPSID:                 uint32(r.ReadGolomb()),
+		EntropyCodingModeFlag: r.Read(1) == 1,
+	}
+	p.BottomFieldPicOrderInFramePresent = r.Read(1) == 1
+	numSliceGroupsMinus1 := r.ReadGolomb()
+	if numSliceGroupsMinus1 > 7 {
+		return nil, fmt.Errorf("h264: parse PPS: num_slice_groups_minus1 %d out of range", numSliceGroupsMinus1)
+	}
+	p.NumSliceGroups = uint32(numSliceGroupsMinus1) + 1
+	if p.NumSliceGroups > 1 {
+		skipSliceGroups(r, p.NumSliceGroups)
+	}
+	p.NumRefIdxL0DefaultActive = uint32(r.ReadGolomb()) + 1
+	p.NumRefIdxL1DefaultActive = uint32(r.ReadGolomb()) + 1
+	p.WeightedPred = r.Read(1) == 1
+	p.WeightedBipredIDC = uint8(r.Read(2))
+	p.PicInitQP = r.ReadSeGolomb() + 26
+	p.PicInitQS = r.ReadSeGolomb() + 26
+	p.ChromaQPIndexOffset = r.ReadSeGolomb()
+	p.DeblockingFilterControlPresent = r.Read(1) == 1
+	p.ConstrainedIntraPred = r.Read(1) == 1
+	p.RedundantPicCntPresent = r.Read(1) == 1
+	if err := r.Err(); err != nil {
+		return nil, fmt.Errorf("h264: parse PPS: %w", err)
+	}
+	return p, nil
+}
+
+func skipSliceGroups(r *bit.Reader, n uint32) {
+	mapType := r.ReadGolomb()
+	switch mapType {
+	case 0:
+		for i := uint32(0); i < n && !r.EOF; i++ {
+			r.ReadGolomb() // run_length_minus1
+		}
+	case 2:
+		for i := uint32(0); i+1 < n && !r.EOF; i++ {
+			r.ReadGolomb() // top_left
+			r.ReadGolomb() // bottom_right
+		}
+	case 3, 4, 5:
+		r.Skip(1)      // slice_group_change_direction_flag
+		r.ReadGolomb() // slice_group_change_rate_minus1
+	case 6:
+		size := r.ReadGolomb() + 1
+		var bits uint32
+		for bits < 32 && (uint32(1)<<bits) < n {
+			bits++
+		}
+		for i := uint64(0); i < size && !r.EOF; i++ {
+			r.Skip(bits)
+		}
+	}
+}
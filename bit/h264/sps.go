@@ -0,0 +1,283 @@
+package h264
+
+import (
+	"fmt"
+
+	"github.com/kakami/pkg/bit"
+)
+
+// VUI holds the video usability information of an SPS, ITU-T H.264 E.1.1.
+type VUI struct {
+	AspectRatioIDC           uint8
+	SARWidth                 uint16
+	SARHeight                uint16
+	OverscanInfoPresent      bool
+	OverscanAppropriate      bool
+	VideoSignalTypePresent   bool
+	VideoFormat              uint8
+	VideoFullRange           bool
+	ColourDescriptionPresent bool
+	ColourPrimaries          uint8
+	TransferCharacteristics  uint8
+	MatrixCoefficients       uint8
+	ChromaLocInfoPresent     bool
+	ChromaSampleLocTop       uint32
+	ChromaSampleLocBottom    uint32
+	TimingInfoPresent        bool
+	NumUnitsInTick           uint32
+	TimeScale                uint32
+	FixedFrameRate           bool
+	NALHRDPresent            bool
+	VCLHRDPresent            bool
+	LowDelayHRD              bool
+	PicStructPresent         bool
+	BitstreamRestriction     bool
+	MaxNumReorderFrames      uint32
+	MaxDecFrameBuffering     uint32
+}
+
+// SPS is a sequence parameter set, ITU-T H.264 7.3.2.1.1.
+type SPS struct {
+	ProfileIDC            uint8
+	ConstraintFlags       uint8
+	LevelIDC              uint8
+	ID                    uint32
+	ChromaFormatIDC       uint32
+	SeparateColourPlane   bool
+	BitDepthLuma          uint32
+	BitDepthChroma        uint32
+	Log2MaxFrameNum       uint32
+	PicOrderCntType       uint32
+	Log2MaxPicOrderCnt    uint32
+	MaxNumRefFrames       uint32
+	PicWidthInMbs         uint32
+	PicHeightInMapUnits   uint32
+	FrameMbsOnly          bool
+	Direct8x8Inference    bool
+	FrameCropping         bool
+	FrameCropLeftOffset   uint32
+	FrameCropRightOffset  uint32
+	FrameCropTopOffset    uint32
+	FrameCropBottomOffset uint32
+	VUIPresent            bool
+	VUI                   VUI
+}
+
+// ParseSPS parses an SPS NAL unit including its header byte.
+func ParseSPS(nalu []byte) (*SPS, error) {
+	if len(nalu) < 4 || TypeOf(nalu) != NALUTypeSPS {
+		return nil, fmt.Errorf("h264: not an SPS")
+	}
+	r := bit.NewReader(RemoveEmulationPrevention(nalu[1:]))
+	s := &SPS{
+		ProfileIDC:      uint8(r.Read(8)),
+		ConstraintFlags: uint8(r.Read(8)),
+		LevelIDC:        uint8(r.Read(8)),
+		ID:              uint32(r.ReadGolomb()),
+		ChromaFormatIDC: 1,
+		BitDepthLuma:    8,
+		BitDepthChroma:  8,
+	}
+	switch s.ProfileIDC {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		s.ChromaFormatIDC = uint32(r.ReadGolomb())
+		if s.ChromaFormatIDC == 3 {
+			s.SeparateColourPlane = r.Read(1) == 1
+		}
+		s.BitDepthLuma = uint32(r.ReadGolomb()) + 8
+		s.BitDepthChroma = uint32(r.ReadGolomb()) + 8
+		r.Skip(1) // qpprime_y_zero_transform_bypass_flag
+		if r.Read(1) == 1 {
+			n := 8
+			if s.ChromaFormatIDC == 3 {
+				n = 12
+			}
+			for i := 0; i < n; i++ {
+				if r.Read(1) == 0 {
+					continue
+				}
+				if i < 6 {
+					skipScalingList(r, 16)
+				} else {
+					skipScalingList(r, 64)
+				}
+			}
+		}
+	}
+
+	s.Log2MaxFrameNum = uint32(r.ReadGolomb()) + 4
+	s.PicOrderCntType = uint32(r.ReadGolomb())
+	switch s.PicOrderCntType {
+	case 0:
+		s.Log2MaxPicOrderCnt = uint32(r.ReadGolomb()) + 4
+	case 1:
+		r.Skip(1) // delta_pic_order_always_zero_flag
+		r.ReadSeGolomb()
+		r.ReadSeGolomb()
+		n := r.ReadGolomb()
+		for i := uint64(0); i < n && !r.EOF; i++ {
+			r.ReadSeGolomb()
+		}
+	}
+	s.MaxNumRefFrames = uint32(r.ReadGolomb())
+	r.Skip(1) // gaps_in_frame_num_value_allowed_flag
+	s.PicWidthInMbs = uint32(r.ReadGolomb()) + 1
+	s.PicHeightInMapUnits = uint32(r.ReadGolomb()) + 1
+	s.FrameMbsOnly = r.Read(1) == 1
+	if !s.FrameMbsOnly {
+		r.Skip(1) // mb_adaptive_frame_field_flag
+	}
+	s.Direct8x8Inference = r.Read(1) == 1
+	s.FrameCropping = r.Read(1) == 1
+	if s.FrameCropping {
+		s.FrameCropLeftOffset = uint32(r.ReadGolomb())
+		s.FrameCropRightOffset = uint32(r.ReadGolomb())
+		s.FrameCropTopOffset = uint32(r.ReadGolomb())
+		s.FrameCropBottomOffset = uint32(r.ReadGolomb())
+	}
+	s.VUIPresent = r.Read(1) == 1
+	if s.VUIPresent {
+		parseVUI(r, &s.VUI)
+	}
+	if err := r.Err(); err != nil {
+		return nil, fmt.Errorf("h264: parse SPS: %w", err)
+	}
+	return s, nil
+}
+
+func skipScalingList(r *bit.Reader, size int) {
+	last, next := 8, 8
+	for j := 0; j < size && !r.EOF; j++ {
+		if next != 0 {
+			delta := r.ReadSeGolomb()
+			next = (last + delta + 256) % 256
+		}
+		if next != 0 {
+			last = next
+		}
+	}
+}
+
+func parseVUI(r *bit.Reader, v *VUI) {
+	if r.Read(1) == 1 {
+		v.AspectRatioIDC = uint8(r.Read(8))
+		if v.AspectRatioIDC == 255 { // Extended_SAR
+			v.SARWidth = uint16(r.Read(16))
+			v.SARHeight = uint16(r.Read(16))
+		}
+	}
+	v.OverscanInfoPresent = r.Read(1) == 1
+	if v.OverscanInfoPresent {
+		v.OverscanAppropriate = r.Read(1) == 1
+	}
+	v.VideoSignalTypePresent = r.Read(1) == 1
+	if v.VideoSignalTypePresent {
+		v.VideoFormat = uint8(r.Read(3))
+		v.VideoFullRange = r.Read(1) == 1
+		v.ColourDescriptionPresent = r.Read(1) == 1
+		if v.ColourDescriptionPresent {
+			v.ColourPrimaries = uint8(r.Read(8))
+			v.TransferCharacteristics = uint8(r.Read(8))
+			v.MatrixCoefficients = uint8(r.Read(8))
+		}
+	}
+	v.ChromaLocInfoPresent = r.Read(1) == 1
+	if v.ChromaLocInfoPresent {
+		v.ChromaSampleLocTop = uint32(r.ReadGolomb())
+		v.ChromaSampleLocBottom = uint32(r.ReadGolomb())
+	}
+	v.TimingInfoPresent = r.Read(1) == 1
+	if v.TimingInfoPresent {
+		v.NumUnitsInTick = uint32(r.Read(32))
+		v.TimeScale = uint32(r.Read(32))
+		v.FixedFrameRate = r.Read(1) == 1
+	}
+	v.NALHRDPresent = r.Read(1) == 1
+	if v.NALHRDPresent {
+		skipHRD(r)
+	}
+	v.VCLHRDPresent = r.Read(1) == 1
+	if v.VCLHRDPresent {
+		skipHRD(r)
+	}
+	if v.NALHRDPresent || v.VCLHRDPresent {
+		v.LowDelayHRD = r.Read(1) == 1
+	}
+	v.PicStructPresent = r.Read(1) == 1
+	v.BitstreamRestriction = r.Read(1) == 1
+	if v.BitstreamRestriction {
+		r.Skip(1)      // motion_vectors_over_pic_boundaries_flag
+		r.ReadGolomb() // max_bytes_per_pic_denom
+		r.ReadGolomb() // max_bits_per_mb_denom
+		r.ReadGolomb() // log2_max_mv_length_horizontal
+		r.ReadGolomb() // log2_max_mv_length_vertical
+		v.MaxNumReorderFrames = uint32(r.ReadGolomb())
+		v.MaxDecFrameBuffering = uint32(r.ReadGolomb())
+	}
+}
+
+func skipHRD(r *bit.Reader) {
+	n := r.ReadGolomb() + 1 // cpb_cnt_minus1
+	r.Skip(4)               // bit_rate_scale
+	r.Skip(4)               // cpb_size_scale
+	for i := uint64(0); i < n && !r.EOF; i++ {
+		r.ReadGolomb() // bit_rate_value_minus1
+		r.ReadGolomb() // cpb_size_value_minus1
+		r.Skip(1)      // cbr_flag
+	}
+	r.Skip(5 + 5 + 5 + 5)
+}
+
+// Width returns the cropped picture width in luma samples.
+func (s *SPS) Width() int {
+	cropUnitX := uint32(1)
+	if s.ChromaFormatIDC != 0 && !s.SeparateColourPlane && s.ChromaFormatIDC < 3 {
+		cropUnitX = 2
+	}
+	return int(s.PicWidthInMbs*16 - cropUnitX*(s.FrameCropLeftOffset+s.FrameCropRightOffset))
+}
+
+// Height returns the cropped picture height in luma samples.
+func (s *SPS) Height() int {
+	frameMbs := uint32(2)
+	if s.FrameMbsOnly {
+		frameMbs = 1
+	}
+	cropUnitY := frameMbs
+	if s.ChromaFormatIDC == 1 && !s.SeparateColourPlane {
+		cropUnitY = 2 * frameMbs
+	}
+	return int(frameMbs*s.PicHeightInMapUnits*16 - cropUnitY*(s.FrameCropTopOffset+s.FrameCropBottomOffset))
+}
+
+// FrameRate returns the frame rate signalled in the VUI timing info, or 0.
+func (s *SPS) FrameRate() float64 {
+	if !s.VUI.TimingInfoPresent || s.VUI.NumUnitsInTick == 0 {
+		return 0
+	}
+	return float64(s.VUI.TimeScale) / float64(2*s.VUI.NumUnitsInTick)
+}
+
+// ProfileName returns a human readable name of the profile.
+func (s *SPS) ProfileName() string {
+	switch s.ProfileIDC {
+	case 66:
+		if s.ConstraintFlags&0x40 != 0 {
+			return "Constrained Baseline"
+		}
+		return "Baseline"
+	case 77:
+		return "Main"
+	case 88:
+		return "Extended"
+	case 100:
+		return "High"
+	case 110:
+		return "High 10"
+	case 122:
+		return "High 4:2:2"
+	case 244:
+		return "High 4:4:4 Predictive"
+	}
+	return fmt.Sprintf("Profile(%d)", s.ProfileIDC)
+}
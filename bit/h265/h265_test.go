@@ -0,0 +1,86 @@
+package h265_test
+
+import (
+	"testing"
+
+	"github.com/kakami/pkg/bit/h265"
+)
+
+var (
+	// x265 1920x1080 Main@L4 30fps
+	_sps1080 = []byte{
+		0x42, 0x01, 0x01, 0x01, 0x60, 0x00, 0x00, 0x03,
+		0x00, 0x90, 0x00, 0x00, 0x03, 0x00, 0x00, 0x03,
+		0x00, 0x78, 0xa0, 0x03, 0xc0, 0x80, 0x10, 0xe5,
+		0x96, 0x66, 0x69, 0x24, 0xca, 0xe0, 0x10, 0x00,
+		0x00, 0x03, 0x00, 0x10, 0x00, 0x00, 0x03, 0x01,
+		0xe0, 0x80,
+	}
+	// 1280x720 Main@L3.1 30fps
+	_sps720 = []byte{
+		0x42, 0x01, 0x01, 0x01, 0x60, 0x00, 0x00, 0x03,
+		0x00, 0x90, 0x00, 0x00, 0x03, 0x00, 0x00, 0x03,
+		0x00, 0x5d, 0xa0, 0x02, 0x80, 0x80, 0x2d, 0x16,
+		0x59, 0x59, 0xa4, 0x93, 0x2b, 0xc0, 0x40, 0x40,
+		0x00, 0x00, 0x03, 0x00, 0x40, 0x00, 0x00, 0x07,
+		0x82,
+	}
+	_pps = []byte{0x44, 0x01, 0xc1, 0x72, 0xb4, 0x62, 0x40}
+)
+
+func Test_ParseSPS(t *testing.T) {
+	cases := []struct {
+		nalu          []byte
+		width, height int
+		level         uint8
+		fps           float64
+	}{
+		{_sps1080, 1920, 1080, 120, 30},
+		{_sps720, 1280, 720, 93, 30},
+	}
+	for _, c := range cases {
+		s, err := h265.ParseSPS(c.nalu)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Width() != c.width || s.Height() != c.height {
+			t.Errorf("got %dx%d, want %dx%d", s.Width(), s.Height(), c.width, c.height)
+		}
+		if s.ProfileTierLevel.ProfileIDC != 1 || s.ProfileTierLevel.LevelIDC != c.level {
+			t.Errorf("got profile %d level %d", s.ProfileTierLevel.ProfileIDC, s.ProfileTierLevel.LevelIDC)
+		}
+		if s.FrameRate() != c.fps {
+			t.Errorf("got %v fps, want %v", s.FrameRate(), c.fps)
+		}
+		if s.ChromaFormatIDC != 1 || s.BitDepthLuma != 8 || !s.VUIPresent {
+			t.Errorf("unexpected sps %+v", s)
+		}
+	}
+	if _, err := h265.ParseSPS(_sps1080[:12]); err == nil {
+		t.Error("expected error for truncated SPS")
+	}
+}
+
+func Test_ParsePPS(t *testing.T) {
+	p, err := h265.ParsePPS(_pps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != 0 || p.SPSID != 0 || !p.SignDataHidingEnabled || !p.CUQPDeltaEnabled ||
+		p.DiffCUQPDeltaDepth != 1 || p.InitQP != 26 || !p.EntropyCodingSyncEnabled {
+		t.Errorf("unexpected pps %+v", p)
+	}
+}
+
+func Test_NALUType(t *testing.T) {
+	stream := append([]byte{0, 0, 0, 1}, _sps1080...)
+	stream = append(stream, 0, 0, 1)
+	stream = append(stream, _pps...)
+	nalus := h265.SplitNALUs(stream)
+	if len(nalus) != 2 || h265.TypeOf(nalus[0]) != h265.NALUTypeSPS || h265.TypeOf(nalus[1]) != h265.NALUTypePPS {
+		t.Fatalf("unexpected nalus %x", nalus)
+	}
+	if h265.NALUTypeCRA.String() != "CRA_NUT" || !h265.NALUTypeIDRNLP.IsIRAP() || h265.NALUTypeTrailR.IsIRAP() {
+		t.Error("unexpected nalu type helpers")
+	}
+}
@@ -0,0 +1,95 @@
+// Package h265 parses H.265/HEVC Annex-B streams and parameter sets.
+package h265
+
+import (
+	"fmt"
+
+	"github.com/kakami/pkg/bit/h264"
+)
+
+// NALUType ...
+type NALUType uint8
+
+// NAL unit types, ITU-T H.265 table 7-1
+const (
+	NALUTypeTrailN    NALUType = 0
+	NALUTypeTrailR    NALUType = 1
+	NALUTypeTSAN      NALUType = 2
+	NALUTypeTSAR      NALUType = 3
+	NALUTypeSTSAN     NALUType = 4
+	NALUTypeSTSAR     NALUType = 5
+	NALUTypeRADLN     NALUType = 6
+	NALUTypeRADLR     NALUType = 7
+	NALUTypeRASLN     NALUType = 8
+	NALUTypeRASLR     NALUType = 9
+	NALUTypeBLAWLP    NALUType = 16
+	NALUTypeBLAWRADL  NALUType = 17
+	NALUTypeBLANLP    NALUType = 18
+	NALUTypeIDRWRADL  NALUType = 19
+	NALUTypeIDRNLP    NALUType = 20
+	NALUTypeCRA       NALUType = 21
+	NALUTypeVPS       NALUType = 32
+	NALUTypeSPS       NALUType = 33
+	NALUTypePPS       NALUType = 34
+	NALUTypeAUD       NALUType = 35
+	NALUTypeEOS       NALUType = 36
+	NALUTypeEOB       NALUType = 37
+	NALUTypeFD        NALUType = 38
+	NALUTypePrefixSEI NALUType = 39
+	NALUTypeSuffixSEI NALUType = 40
+)
+
+var _naluTypeNames = map[NALUType]string{
+	NALUTypeTrailN:    "TRAIL_N",
+	NALUTypeTrailR:    "TRAIL_R",
+	NALUTypeTSAN:      "TSA_N",
+	NALUTypeTSAR:      "TSA_R",
+	NALUTypeSTSAN:     "STSA_N",
+	NALUTypeSTSAR:     "STSA_R",
+	NALUTypeRADLN:     "RADL_N",
+	NALUTypeRADLR:     "RADL_R",
+	NALUTypeRASLN:     "RASL_N",
+	NALUTypeRASLR:     "RASL_R",
+	NALUTypeBLAWLP:    "BLA_W_LP",
+	NALUTypeBLAWRADL:  "BLA_W_RADL",
+	NALUTypeBLANLP:    "BLA_N_LP",
+	NALUTypeIDRWRADL:  "IDR_W_RADL",
+	NALUTypeIDRNLP:    "IDR_N_LP",
+	NALUTypeCRA:       "CRA_NUT",
+	NALUTypeVPS:       "VPS",
+	NALUTypeSPS:       "SPS",
+	NALUTypePPS:       "PPS",
+	NALUTypeAUD:       "AUD",
+	NALUTypeEOS:       "EOS",
+	NALUTypeEOB:       "EOB",
+	NALUTypeFD:        "FD",
+	NALUTypePrefixSEI: "PREFIX_SEI",
+	NALUTypeSuffixSEI: "SUFFIX_SEI",
+}
+
+func (t NALUType) String() string {
+	if s, ok := _naluTypeNames[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("NALUType(%d)", uint8(t))
+}
+
+// IsIRAP reports whether t is an intra random access point picture.
+func (t NALUType) IsIRAP() bool {
+	return t >= NALUTypeBLAWLP && t <= 23
+}
+
+// TypeOf returns the type of a NAL unit, nalu must not be empty.
+func TypeOf(nalu []byte) NALUType {
+	return NALUType((nalu[0] >> 1) & 0x3f)
+}
+
+// SplitNALUs splits an Annex-B byte stream, see h264.SplitNALUs.
+func SplitNALUs(data []byte) [][]byte {
+	return h264.SplitNALUs(data)
+}
+
+// RemoveEmulationPrevention converts EBSP to RBSP, see h264.RemoveEmulationPrevention.
+func RemoveEmulationPrevention(data []byte) []byte {
+	return h264.RemoveEmulationPrevention(data)
+}
@@ -0,0 +1,72 @@
+package h265
+
+import (
+	"fmt"
+
+	"github.com/kakami/pkg/bit"
+)
+
+// PPS is a picture parameter set, ITU-T H.265 7.3.2.3. Fields after
+// EntropyCodingSyncEnabled are not parsed.
+type PPS struct {
+	ID                            uint32
+	SPSID                         uint32
+	DependentSliceSegmentsEnabled bool
+	OutputFlagPresent             bool
+	NumExtraSliceHeaderBits       uint8
+	SignDataHidingEnabled         bool
+	CABACInitPresent              bool
+	NumRefIdxL0DefaultActive      uint32
+	NumRefIdxL1DefaultActive      uint32
+	InitQP                        int
+	ConstrainedIntraPred          bool
+	TransformSkipEnabled          bool
+	CUQPDeltaEnabled              bool
+	DiffCUQPDeltaDepth            uint32
+	CbQPOffset                    int
+	CrQPOffset                    int
+	SliceChromaQPOffsetsPresent   bool
+	WeightedPred                  bool
+	WeightedBipred                bool
+	TransquantBypassEnabled       bool
+	TilesEnabled                  bool
+	EntropyCodingSyncEnabled      bool
+}
+
+// ParsePPS parses a PPS NAL unit including its two header bytes.
+func ParsePPS(nalu []byte) (*PPS, error) {
+	if len(nalu) < 3 || TypeOf(nalu) != NALUTypePPS {
+		return nil, fmt.Errorf("h265: not a PPS")
+	}
+	r := bit.NewReader(RemoveEmulationPrevention(nalu[2:]))
+	p := &PPS{
+		ID:    uint32(r.ReadGolomb()),
+		SPSID: uint32(r.ReadGolomb()),
+	}
+	p.DependentSliceSegmentsEnabled = r.Read(1) == 1
+	p.OutputFlagPresent = r.Read(1) == 1
+	p.NumExtraSliceHeaderBits = uint8(r.Read(3))
+	p.SignDataHidingEnabled = r.Read(1) == 1
+	p.CABACInitPresent = r.Read(1) == 1
+	p.NumRefIdxL0DefaultActive = uint32(r.ReadGolomb()) + 1
+	p.NumRefIdxL1DefaultActive = uint32(r.ReadGolomb()) + 1
+	p.InitQP = r.ReadSeGolomb() + 26
+	p.ConstrainedIntraPred = r.Read(1) == 1
+	p.TransformSkipEnabled = r.Read(1) == 1
+	p.CUQPDeltaEnabled = r.Read(1) == 1
+	if p.CUQPDeltaEnabled {
+		p.DiffCUQPDeltaDepth = uint32(r.ReadGolomb())
+	}
+	p.CbQPOffset = r.ReadSeGolomb()
+	p.CrQPOffset = r.ReadSeGolomb()
+	p.SliceChromaQPOffsetsPresent = r.Read(1) == 1
+	p.WeightedPred = r.Read(1) == 1
+	p.WeightedBipred = r.Read(1) == 1
+	p.TransquantBypassEnabled = r.Read(1) == 1
+	p.TilesEnabled = r.Read(1) == 1
+	p.EntropyCodingSyncEnabled = r.Read(1) == 1
+	if err := r.Err(); err != nil {
+		return nil, fmt.Errorf("h265: parse PPS: %w", err)
+	}
+	return p, nil
+}
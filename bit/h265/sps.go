@@ -0,0 +1,335 @@
+package h265
+
+import (
+	"fmt"
+
+	"github.com/kakami/pkg/bit"
+)
+
+// ProfileTierLevel holds the general profile, tier and level, ITU-T H.265 7.3.3.
+type ProfileTierLevel struct {
+	ProfileSpace         uint8
+	TierFlag             bool
+	ProfileIDC           uint8
+	ProfileCompatibility uint32
+	ProgressiveSource    bool
+	InterlacedSource     bool
+	NonPackedConstraint  bool
+	FrameOnlyConstraint  bool
+	LevelIDC             uint8
+}
+
+// VUI holds the video usability information of an SPS, ITU-T H.265 E.2.1.
+// Fields after the timing info are not parsed.
+type VUI struct {
+	AspectRatioIDC           uint8
+	SARWidth                 uint16
+	SARHeight                uint16
+	OverscanInfoPresent      bool
+	OverscanAppropriate      bool
+	VideoSignalTypePresent   bool
+	VideoFormat              uint8
+	VideoFullRange           bool
+	ColourDescriptionPresent bool
+	ColourPrimaries          uint8
+	TransferCharacteristics  uint8
+	MatrixCoefficients       uint8
+	ChromaLocInfoPresent     bool
+	ChromaSampleLocTop       uint32
+	ChromaSampleLocBottom    uint32
+	NeutralChroma            bool
+	FieldSeq                 bool
+	FrameFieldInfoPresent    bool
+	DefaultDisplayWindow     bool
+	DefDispWinLeftOffset     uint32
+	DefDispWinRightOffset    uint32
+	DefDispWinTopOffset      uint32
+	DefDispWinBottomOffset   uint32
+	TimingInfoPresent        bool
+	NumUnitsInTick           uint32
+	TimeScale                uint32
+}
+
+// SPS is a sequence parameter set, ITU-T H.265 7.3.2.2.
+type SPS struct {
+	VPSID                   uint8
+	MaxSubLayers            uint8
+	TemporalIDNesting       bool
+	ProfileTierLevel        ProfileTierLevel
+	ID                      uint32
+	ChromaFormatIDC         uint32
+	SeparateColourPlane     bool
+	PicWidthInLumaSamples   uint32
+	PicHeightInLumaSamples  uint32
+	ConformanceWindow       bool
+	ConfWinLeftOffset       uint32
+	ConfWinRightOffset      uint32
+	ConfWinTopOffset        uint32
+	ConfWinBottomOffset     uint32
+	BitDepthLuma            uint32
+	BitDepthChroma          uint32
+	Log2MaxPicOrderCntLsb   uint32
+	MaxDecPicBuffering      []uint32
+	MaxNumReorderPics       []uint32
+	MaxLatencyIncrease      []uint32
+	Log2MinLumaCodingBlock  uint32
+	Log2DiffMaxMinLumaBlock uint32
+	AMPEnabled              bool
+	SAOEnabled              bool
+	PCMEnabled              bool
+	NumShortTermRefPicSets  uint32
+	LongTermRefPicsPresent  bool
+	TemporalMVPEnabled      bool
+	StrongIntraSmoothing    bool
+	VUIPresent              bool
+	VUI                     VUI
+}
+
+// ParseSPS parses an SPS NAL unit including its two header bytes.
+func ParseSPS(nalu []byte) (*SPS, error) {
+	if len(nalu) < 4 || TypeOf(nalu) != NALUTypeSPS {
+		return nil, fmt.Errorf("h265: not an SPS")
+	}
+	r := bit.NewReader(RemoveEmulationPrevention(nalu[2:]))
+	s := &SPS{
+		VPSID:        uint8(r.Read(4)),
+		MaxSubLayers: uint8(r.Read(3)) + 1,
+	}
+	s.TemporalIDNesting = r.Read(1) == 1
+	parseProfileTierLevel(r, &s.ProfileTierLevel, s.MaxSubLayers)
+
+	s.ID = uint32(r.ReadGolomb())
+	s.ChromaFormatIDC = uint32(r.ReadGolomb())
+	if s.ChromaFormatIDC == 3 {
+		s.SeparateColourPlane = r.Read(1) == 1
+	}
+	s.PicWidthInLumaSamples = uint32(r.ReadGolomb())
+	s.PicHeightInLumaSamples = uint32(r.ReadGolomb())
+	s.ConformanceWindow = r.Read(1) == 1
+	if s.ConformanceWindow {
+		s.ConfWinLeftOffset = uint32(r.ReadGolomb())
+		s.ConfWinRightOffset = uint32(r.ReadGolomb())
+		s.ConfWinTopOffset = uint32(r.ReadGolomb())
+		s.ConfWinBottomOffset = uint32(r.ReadGolomb())
+	}
+	s.BitDepthLuma = uint32(r.ReadGolomb()) + 8
+	s.BitDepthChroma = uint32(r.ReadGolomb()) + 8
+	s.Log2MaxPicOrderCntLsb = uint32(r.ReadGolomb()) + 4
+
+	start := int(s.MaxSubLayers) - 1
+	if r.Read(1) == 1 { // sps_sub_layer_ordering_info_present_flag
+		start = 0
+	}
+	for i := start; i < int(s.MaxSubLayers) && !r.EOF; i++ {
+		s.MaxDecPicBuffering = append(s.MaxDecPicBuffering, uint32(r.ReadGolomb())+1)
+		s.MaxNumReorderPics = append(s.MaxNumReorderPics, uint32(r.ReadGolomb()))
+		s.MaxLatencyIncrease = append(s.MaxLatencyIncrease, uint32(r.ReadGolomb()))
+	}
+
+	s.Log2MinLumaCodingBlock = uint32(r.ReadGolomb()) + 3
+	s.Log2DiffMaxMinLumaBlock = uint32(r.ReadGolomb())
+	r.ReadGolomb() // log2_min_luma_transform_block_size_minus2
+	r.ReadGolomb() // log2_diff_max_min_luma_transform_block_size
+	r.ReadGolomb() // max_transform_hierarchy_depth_inter
+	r.ReadGolomb() // max_transform_hierarchy_depth_intra
+	// scaling_list_enabled_flag, sps_scaling_list_data_present_flag
+	if r.Read(1) == 1 && r.Read(1) == 1 {
+		skipScalingListData(r)
+	}
+	s.AMPEnabled = r.Read(1) == 1
+	s.SAOEnabled = r.Read(1) == 1
+	s.PCMEnabled = r.Read(1) == 1
+	if s.PCMEnabled {
+		r.Skip(4 + 4)  // pcm_sample_bit_depth_luma/chroma_minus1
+		r.ReadGolomb() // log2_min_pcm_luma_coding_block_size_minus3
+		r.ReadGolomb() // log2_diff_max_min_pcm_luma_coding_block_size
+		r.Skip(1)      // pcm_loop_filter_disabled_flag
+	}
+	s.NumShortTermRefPicSets = uint32(r.ReadGolomb())
+	if s.NumShortTermRefPicSets > 64 {
+		return nil, fmt.Errorf("h265: parse SPS: invalid num_short_term_ref_pic_sets %d", s.NumShortTermRefPicSets)
+	}
+	numDeltaPocs := make([]uint32, s.NumShortTermRefPicSets)
+	for i := uint32(0); i < s.NumShortTermRefPicSets && !r.EOF; i++ {
+		n, ok := skipShortTermRefPicSet(r, i, numDeltaPocs)
+		if !ok {
+			return nil, fmt.Errorf("h265: parse SPS: invalid st_ref_pic_set %d", i)
+		}
+		numDeltaPocs[i] = n
+	}
+	s.LongTermRefPicsPresent = r.Read(1) == 1
+	if s.LongTermRefPicsPresent {
+		n := r.ReadGolomb()
+		for i := uint64(0); i < n && !r.EOF; i++ {
+			r.Skip(s.Log2MaxPicOrderCntLsb) // lt_ref_pic_poc_lsb_sps
+			r.Skip(1)                       // used_by_curr_pic_lt_sps_flag
+		}
+	}
+	s.TemporalMVPEnabled = r.Read(1) == 1
+	s.StrongIntraSmoothing = r.Read(1) == 1
+	s.VUIPresent = r.Read(1) == 1
+	if s.VUIPresent {
+		parseVUI(r, &s.VUI)
+	}
+	if err := r.Err(); err != nil {
+		return nil, fmt.Errorf("h265: parse SPS: %w", err)
+	}
+	return s, nil
+}
+
+func parseProfileTierLevel(r *bit.Reader, p *ProfileTierLevel, maxSubLayers uint8) {
+	p.ProfileSpace = uint8(r.Read(2))
+	p.TierFlag = r.Read(1) == 1
+	p.ProfileIDC = uint8(r.Read(5))
+	p.ProfileCompatibility = uint32(r.Read(32))
+	p.ProgressiveSource = r.Read(1) == 1
+	p.InterlacedSource = r.Read(1) == 1
+	p.NonPackedConstraint = r.Read(1) == 1
+	p.FrameOnlyConstraint = r.Read(1) == 1
+	r.Skip(44) // general_reserved_zero_43bits, general_inbld_flag
+	p.LevelIDC = uint8(r.Read(8))
+
+	n := int(maxSubLayers) - 1
+	profilePresent := make([]bool, n)
+	levelPresent := make([]bool, n)
+	for i := 0; i < n; i++ {
+		profilePresent[i] = r.Read(1) == 1
+		levelPresent[i] = r.Read(1) == 1
+	}
+	if n > 0 {
+		for i := n; i < 8; i++ {
+			r.Skip(2) // reserved_zero_2bits
+		}
+	}
+	for i := 0; i < n; i++ {
+		if profilePresent[i] {
+			r.Skip(88)
+		}
+		if levelPresent[i] {
+			r.Skip(8)
+		}
+	}
+}
+
+func skipScalingListData(r *bit.Reader) {
+	for sizeID := 0; sizeID < 4; sizeID++ {
+		step := 1
+		if sizeID == 3 {
+			step = 3
+		}
+		for matrixID := 0; matrixID < 6 && !r.EOF; matrixID += step {
+			if r.Read(1) == 0 { // scaling_list_pred_mode_flag
+				r.ReadGolomb() // scaling_list_pred_matrix_id_delta
+				continue
+			}
+			coefNum := min(64, 1<<(4+(sizeID<<1)))
+			if sizeID > 1 {
+				r.ReadSeGolomb() // scaling_list_dc_coef_minus8
+			}
+			for i := 0; i < coefNum && !r.EOF; i++ {
+				r.ReadSeGolomb() // scaling_list_delta_coef
+			}
+		}
+	}
+}
+
+// skipShortTermRefPicSet skips st_ref_pic_set(idx) and returns its NumDeltaPocs.
+func skipShortTermRefPicSet(r *bit.Reader, idx uint32, numDeltaPocs []uint32) (uint32, bool) {
+	if idx != 0 && r.Read(1) == 1 { // inter_ref_pic_set_prediction_flag
+		r.Skip(1)      // delta_rps_sign
+		r.ReadGolomb() // abs_delta_rps_minus1
+		var n uint32
+		for j := uint32(0); j <= numDeltaPocs[idx-1] && !r.EOF; j++ {
+			used := r.Read(1) == 1
+			useDelta := true
+			if !used {
+				useDelta = r.Read(1) == 1
+			}
+			if used || useDelta {
+				n++
+			}
+		}
+		return n, true
+	}
+	neg := r.ReadGolomb()
+	pos := r.ReadGolomb()
+	if neg > 16 || pos > 16 {
+		return 0, false
+	}
+	for i := uint64(0); i < neg+pos && !r.EOF; i++ {
+		r.ReadGolomb() // delta_poc_s0/s1_minus1
+		r.Skip(1)      // used_by_curr_pic_s0/s1_flag
+	}
+	return uint32(neg + pos), true
+}
+
+func parseVUI(r *bit.Reader, v *VUI) {
+	if r.Read(1) == 1 {
+		v.AspectRatioIDC = uint8(r.Read(8))
+		if v.AspectRatioIDC == 255 { // EXTENDED_SAR
+			v.SARWidth = uint16(r.Read(16))
+			v.SARHeight = uint16(r.Read(16))
+		}
+	}
+	v.OverscanInfoPresent = r.Read(1) == 1
+	if v.OverscanInfoPresent {
+		v.OverscanAppropriate = r.Read(1) == 1
+	}
+	v.VideoSignalTypePresent = r.Read(1) == 1
+	if v.VideoSignalTypePresent {
+		v.VideoFormat = uint8(r.Read(3))
+		v.VideoFullRange = r.Read(1) == 1
+		v.ColourDescriptionPresent = r.Read(1) == 1
+		if v.ColourDescriptionPresent {
+			v.ColourPrimaries = uint8(r.Read(8))
+			v.TransferCharacteristics = uint8(r.Read(8))
+			v.MatrixCoefficients = uint8(r.Read(8))
+		}
+	}
+	v.ChromaLocInfoPresent = r.Read(1) == 1
+	if v.ChromaLocInfoPresent {
+		v.ChromaSampleLocTop = uint32(r.ReadGolomb())
+		v.ChromaSampleLocBottom = uint32(r.ReadGolomb())
+	}
+	v.NeutralChroma = r.Read(1) == 1
+	v.FieldSeq = r.Read(1) == 1
+	v.FrameFieldInfoPresent = r.Read(1) == 1
+	v.DefaultDisplayWindow = r.Read(1) == 1
+	if v.DefaultDisplayWindow {
+		v.DefDispWinLeftOffset = uint32(r.ReadGolomb())
+		v.DefDispWinRightOffset = uint32(r.ReadGolomb())
+		v.DefDispWinTopOffset = uint32(r.ReadGolomb())
+		v.DefDispWinBottomOffset = uint32(r.ReadGolomb())
+	}
+	v.TimingInfoPresent = r.Read(1) == 1
+	if v.TimingInfoPresent {
+		v.NumUnitsInTick = uint32(r.Read(32))
+		v.TimeScale = uint32(r.Read(32))
+	}
+}
+
+// Width returns the picture width cropped to the conformance window.
+func (s *SPS) Width() int {
+	subWidthC := uint32(1)
+	if !s.SeparateColourPlane && (s.ChromaFormatIDC == 1 || s.ChromaFormatIDC == 2) {
+		subWidthC = 2
+	}
+	return int(s.PicWidthInLumaSamples - subWidthC*(s.ConfWinLeftOffset+s.ConfWinRightOffset))
+}
+
+// Height returns the picture height cropped to the conformance window.
+func (s *SPS) Height() int {
+	subHeightC := uint32(1)
+	if !s.SeparateColourPlane && s.ChromaFormatIDC == 1 {
+		subHeightC = 2
+	}
+	return int(s.PicHeightInLumaSamples - subHeightC*(s.ConfWinTopOffset+s.ConfWinBottomOffset))
+}
+
+// FrameRate returns the frame rate signalled in the VUI timing info, or 0.
+func (s *SPS) FrameRate() float64 {
+	if !s.VUI.TimingInfoPresent || s.VUI.NumUnitsInTick == 0 {
+		return 0
+	}
+	return float64(s.VUI.TimeScale) / float64(s.VUI.NumUnitsInTick)
+}
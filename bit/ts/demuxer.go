@@ -0,0 +1,317 @@
+package ts
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sort"
+)
+
+// Frame is one elementary stream access unit, the payload of a PES packet.
+type Frame struct {
+	PID           uint16
+	Program       uint16
+	StreamType    StreamType
+	StreamID      uint8
+	PTS           int64 // 90kHz, or NoPTS
+	DTS           int64
+	RandomAccess  bool // random_access_indicator on the first packet
+	Discontinuity bool // packets were lost before this frame
+	Data          []byte
+}
+
+type pesStream struct {
+	program       uint16
+	typ           StreamType
+	buf           []byte
+	started       bool
+	randomAccess  bool
+	discontinuity bool
+}
+
+type sectionBuffer struct {
+	buf     []byte
+	started bool
+}
+
+// Demuxer reads transport stream packets and returns the elementary
+// stream frames of all programs announced in the PAT.
+type Demuxer struct {
+	r   *bufio.Reader
+	buf [PacketSize]byte
+
+	cc       map[uint16]uint8
+	sections map[uint16]*sectionBuffer
+	pmtPIDs  map[uint16]uint16 // PMT PID to program number
+	streams  map[uint16]*pesStream
+	pat      *PAT
+	pmts     map[uint16]*PMT
+	frames   []*Frame
+	err      error
+}
+
+// NewDemuxer returns a Demuxer reading packets from r.
+func NewDemuxer(r io.Reader) *Demuxer {
+	return &Demuxer{
+		r:        bufio.NewReaderSize(r, 4*PacketSize),
+		cc:       make(map[uint16]uint8),
+		sections: make(map[uint16]*sectionBuffer),
+		pmtPIDs:  make(map[uint16]uint16),
+		streams:  make(map[uint16]*pesStream),
+		pmts:     make(map[uint16]*PMT),
+	}
+}
+
+// PAT returns the last program association table, or nil.
+func (d *Demuxer) PAT() *PAT {
+	return d.pat
+}
+
+// PMT returns the program map table of a program, or nil.
+func (d *Demuxer) PMT(program uint16) *PMT {
+	return d.pmts[program]
+}
+
+// ReadFrame returns the next complete frame. Frames with an unbounded PES
+// length are returned when the next PES of the PID starts, or at the end
+// of the input. It returns io.EOF once all frames are consumed.
+func (d *Demuxer) ReadFrame() (*Frame, error) {
+	for len(d.frames) == 0 {
+		if d.err != nil {
+			return nil, d.err
+		}
+		if err := d.readPacket(); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				d.flush()
+				err = io.EOF
+			}
+			d.err = err
+		}
+	}
+	f := d.frames[0]
+	d.frames[0] = nil
+	d.frames = d.frames[1:]
+	return f, nil
+}
+
+// readPacket reads the next packet, skipping bytes until a sync byte is
+// followed by another one a packet later.
+func (d *Demuxer) readPacket() error {
+	for {
+		b, err := d.r.Peek(PacketSize + 1)
+		if len(b) < PacketSize {
+			return err // a trailing partial packet is dropped
+		}
+		if b[0] == SyncByte && (len(b) == PacketSize || b[PacketSize] == SyncByte) {
+			copy(d.buf[:], b)
+			_, _ = d.r.Discard(PacketSize)
+			return d.writePacket(d.buf[:])
+		}
+		_, _ = d.r.Discard(1)
+	}
+}
+
+// writePacket feeds one packet to the PSI or PES reassembly of its PID.
+func (d *Demuxer) writePacket(b []byte) error {
+	p, err := ParsePacket(b)
+	if err != nil {
+		return err
+	}
+	if p.PID == PIDNull || p.TransportError {
+		return nil
+	}
+	if !d.continuous(p) {
+		return nil
+	}
+	if !p.HasPayload || p.Scrambling != 0 {
+		return nil
+	}
+	if p.PID == PIDPAT {
+		d.writeSection(p)
+		return nil
+	}
+	if _, ok := d.pmtPIDs[p.PID]; ok {
+		d.writeSection(p)
+		return nil
+	}
+	if s, ok := d.streams[p.PID]; ok {
+		d.writePES(p, s)
+	}
+	return nil
+}
+
+// continuous checks the continuity counter of p and reports whether the
+// packet should be processed, duplicate packets are dropped.
+func (d *Demuxer) continuous(p *Packet) bool {
+	last, seen := d.cc[p.PID]
+	d.cc[p.PID] = p.ContinuityCounter
+	if !seen || !p.HasPayload || (p.Adaptation != nil && p.Adaptation.Discontinuity) {
+		return true
+	}
+	if p.ContinuityCounter == last {
+		return false
+	}
+	if p.ContinuityCounter == (last+1)&0x0f {
+		return true
+	}
+	// packets lost, drop partial data
+	if s, ok := d.streams[p.PID]; ok {
+		s.buf = s.buf[:0]
+		s.started = false
+		s.discontinuity = true
+	}
+	if sb, ok := d.sections[p.PID]; ok {
+		sb.buf = sb.buf[:0]
+		sb.started = false
+	}
+	return true
+}
+
+func (d *Demuxer) writeSection(p *Packet) {
+	sb := d.sections[p.PID]
+	if sb == nil {
+		sb = &sectionBuffer{}
+		d.sections[p.PID] = sb
+	}
+	payload := p.Payload
+	if p.PayloadUnitStart {
+		if len(payload) == 0 {
+			return
+		}
+		ptr := int(payload[0])
+		payload = payload[1:]
+		if ptr > len(payload) {
+			sb.buf = sb.buf[:0]
+			sb.started = false
+			return
+		}
+		if sb.started {
+			sb.buf = append(sb.buf, payload[:ptr]...)
+			d.readSections(p.PID, sb)
+		}
+		sb.buf = sb.buf[:0]
+		sb.started = true
+		payload = payload[ptr:]
+	} else if !sb.started {
+		return
+	}
+	sb.buf = append(sb.buf, payload...)
+	d.readSections(p.PID, sb)
+}
+
+// readSections handles all complete sections in sb.
+func (d *Demuxer) readSections(pid uint16, sb *sectionBuffer) {
+	for sb.started && len(sb.buf) >= 3 {
+		if sb.buf[0] == 0xff { // stuffing
+			sb.buf = sb.buf[:0]
+			sb.started = false
+			return
+		}
+		n := 3 + (int(sb.buf[1]&0x0f)<<8 | int(sb.buf[2]))
+		if len(sb.buf) < n {
+			return
+		}
+		d.handleSection(pid, sb.buf[:n])
+		sb.buf = sb.buf[:copy(sb.buf, sb.buf[n:])]
+	}
+}
+
+// handleSection applies a PAT or PMT, sections failing to parse are
+// dropped like packets with a bad CRC are in a decoder.
+func (d *Demuxer) handleSection(pid uint16, b []byte) {
+	switch {
+	case pid == PIDPAT && b[0] == TableIDPAT:
+		pat, err := ParsePAT(b)
+		if err != nil {
+			return
+		}
+		d.pat = pat
+		for _, prog := range pat.Programs {
+			if prog.Number != 0 {
+				d.pmtPIDs[prog.PID] = prog.Number
+			}
+		}
+	case b[0] == TableIDPMT:
+		pmt, err := ParsePMT(b)
+		if err != nil || pmt.ProgramNumber != d.pmtPIDs[pid] {
+			return
+		}
+		d.pmts[pmt.ProgramNumber] = pmt
+		for _, st := range pmt.Streams {
+			s, ok := d.streams[st.PID]
+			if !ok {
+				s = &pesStream{}
+				d.streams[st.PID] = s
+			}
+			s.program = pmt.ProgramNumber
+			s.typ = st.Type
+		}
+	}
+}
+
+func (d *Demuxer) writePES(p *Packet, s *pesStream) {
+	if p.PayloadUnitStart {
+		if s.started {
+			d.emit(p.PID, s)
+		}
+		s.started = true
+		s.buf = s.buf[:0]
+		s.randomAccess = p.Adaptation != nil && p.Adaptation.RandomAccess
+	} else if !s.started {
+		return
+	}
+	s.buf = append(s.buf, p.Payload...)
+
+	if len(s.buf) >= 6 {
+		if n := int(s.buf[4])<<8 | int(s.buf[5]); n != 0 && len(s.buf) >= 6+n {
+			d.emit(p.PID, s)
+		}
+	}
+}
+
+// emit turns the buffered PES packet of s into a frame.
+func (d *Demuxer) emit(pid uint16, s *pesStream) {
+	defer func() {
+		s.buf = s.buf[:0]
+		s.started = false
+	}()
+	h, err := ParsePESHeader(s.buf)
+	if err != nil {
+		return
+	}
+	end := len(s.buf)
+	if h.PacketLength != 0 {
+		if 6+h.PacketLength > end {
+			return // truncated
+		}
+		end = 6 + h.PacketLength
+	}
+	data := make([]byte, end-h.HeaderLen)
+	copy(data, s.buf[h.HeaderLen:end])
+	d.frames = append(d.frames, &Frame{
+		PID:           pid,
+		Program:       s.program,
+		StreamType:    s.typ,
+		StreamID:      h.StreamID,
+		PTS:           h.PTS,
+		DTS:           h.DTS,
+		RandomAccess:  s.randomAccess,
+		Discontinuity: s.discontinuity,
+		Data:          data,
+	})
+	s.discontinuity = false
+}
+
+// flush emits the pending unbounded PES packets at the end of input.
+func (d *Demuxer) flush() {
+	pids := make([]uint16, 0, len(d.streams))
+	for pid, s := range d.streams {
+		if s.started {
+			pids = append(pids, pid)
+		}
+	}
+	sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+	for _, pid := range pids {
+		d.emit(pid, d.streams[pid])
+	}
+}
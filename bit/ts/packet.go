@@ -0,0 +1,91 @@
+// Package ts demultiplexes MPEG transport streams, ISO/IEC 13818-1.
+package ts
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Transport stream constants
+const (
+	PacketSize = 188
+	SyncByte   = 0x47
+
+	PIDPAT  uint16 = 0x0000
+	PIDNull uint16 = 0x1fff
+)
+
+var (
+	// ErrSync is returned when a packet does not start with the sync byte.
+	ErrSync = errors.New("ts: sync byte not found")
+	// ErrCRC is returned for PSI sections failing the CRC check.
+	ErrCRC = errors.New("ts: section CRC mismatch")
+)
+
+// AdaptationField is the part of the adaptation field the demuxer uses.
+type AdaptationField struct {
+	Discontinuity bool
+	RandomAccess  bool
+	HasPCR        bool
+	PCR           int64 // 27MHz
+}
+
+// Packet is a parsed transport stream packet, Payload aliases the input.
+type Packet struct {
+	TransportError    bool
+	PayloadUnitStart  bool
+	PID               uint16
+	Scrambling        uint8
+	ContinuityCounter uint8
+	Adaptation        *AdaptationField
+	HasPayload        bool
+	Payload           []byte
+}
+
+// ParsePacket parses one 188-byte packet.
+func ParsePacket(b []byte) (*Packet, error) {
+	if len(b) < PacketSize {
+		return nil, fmt.Errorf("ts: short packet of %d bytes", len(b))
+	}
+	if b[0] != SyncByte {
+		return nil, ErrSync
+	}
+	b = b[:PacketSize]
+	p := &Packet{
+		TransportError:    b[1]&0x80 != 0,
+		PayloadUnitStart:  b[1]&0x40 != 0,
+		PID:               uint16(b[1]&0x1f)<<8 | uint16(b[2]),
+		Scrambling:        b[3] >> 6,
+		ContinuityCounter: b[3] & 0x0f,
+		HasPayload:        b[3]&0x10 != 0,
+	}
+	pos := 4
+	if b[3]&0x20 != 0 {
+		n := int(b[4])
+		if pos+1+n > PacketSize {
+			return nil, fmt.Errorf("ts: adaptation field length %d too large", n)
+		}
+		p.Adaptation = parseAdaptationField(b[5 : 5+n])
+		pos += 1 + n
+	}
+	if p.HasPayload {
+		p.Payload = b[pos:]
+	}
+	return p, nil
+}
+
+func parseAdaptationField(b []byte) *AdaptationField {
+	af := &AdaptationField{}
+	if len(b) == 0 {
+		return af
+	}
+	af.Discontinuity = b[0]&0x80 != 0
+	af.RandomAccess = b[0]&0x40 != 0
+	if b[0]&0x10 != 0 && len(b) >= 7 {
+		base := int64(b[1])<<25 | int64(b[2])<<17 | int64(b[3])<<9 | int64(b[4])<<1 | int64(b[5])>>7
+		ext := int64(b[5]&0x01)<<8 | int64(b[6])
+		af.HasPCR = true
+		af.PCR = base*300 + ext
+	}
+	return af
+}
@@ -0,0 +1,76 @@
+package ts
+
+import (
+	"fmt"
+
+	"github.com/kakami/pkg/bit"
+)
+
+// NoPTS marks an absent timestamp.
+const NoPTS int64 = -1
+
+// PESHeader is a PES packet header, ISO/IEC 13818-1 2.4.3.6.
+type PESHeader struct {
+	StreamID     uint8
+	PacketLength int   // 0 for unbounded video packets
+	PTS          int64 // 90kHz, or NoPTS
+	DTS          int64 // 90kHz, equal to PTS when not signalled
+	HeaderLen    int   // offset of the payload
+}
+
+// ParsePESHeader parses the header at the start of a PES packet.
+func ParsePESHeader(b []byte) (*PESHeader, error) {
+	if len(b) < 6 {
+		return nil, bit.ErrShortBuffer
+	}
+	if b[0] != 0 || b[1] != 0 || b[2] != 1 {
+		return nil, fmt.Errorf("ts: invalid PES start code prefix")
+	}
+	h := &PESHeader{
+		StreamID:     b[3],
+		PacketLength: int(b[4])<<8 | int(b[5]),
+		PTS:          NoPTS,
+		DTS:          NoPTS,
+		HeaderLen:    6,
+	}
+	switch h.StreamID {
+	case 0xbc, 0xbe, 0xbf, 0xf0, 0xf1, 0xf2, 0xf8, 0xff:
+		// no optional header
+		return h, nil
+	}
+	if len(b) < 9 {
+		return nil, bit.ErrShortBuffer
+	}
+	if b[6]&0xc0 != 0x80 {
+		return nil, fmt.Errorf("ts: invalid PES header marker")
+	}
+	h.HeaderLen = 9 + int(b[8])
+	if len(b) < h.HeaderLen {
+		return nil, bit.ErrShortBuffer
+	}
+	flags := b[7] >> 6
+	if flags&0x2 != 0 {
+		if h.HeaderLen < 14 {
+			return nil, fmt.Errorf("ts: PES header too short for PTS")
+		}
+		h.PTS = parseTimestamp(b[9:14])
+		h.DTS = h.PTS
+	}
+	if flags == 0x3 {
+		if h.HeaderLen < 19 {
+			return nil, fmt.Errorf("ts: PES header too short for DTS")
+		}
+		h.DTS = parseTimestamp(b[14:19])
+	}
+	if h.PacketLength != 0 && h.PacketLength+6 < h.HeaderLen {
+		return nil, fmt.Errorf("ts: PES packet length %d too small", h.PacketLength)
+	}
+	return h, nil
+}
+
+// 33 bit timestamp split by marker bits
+func parseTimestamp(b []byte) int64 {
+	return int64(b[0]>>1&0x07)<<30 |
+		int64(b[1])<<22 | int64(b[2]>>1)<<15 |
+		int64(b[3])<<7 | int64(b[4]>>1)
+}
@@ -0,0 +1,218 @@
+package ts
+
+import (
+	"fmt"
+
+	"github.com/kakami/pkg/bit"
+)
+
+// Table ids
+const (
+	TableIDPAT = 0x00
+	TableIDPMT = 0x02
+)
+
+// StreamType is the stream_type of a PMT entry, ISO/IEC 13818-1 table 2-34.
+type StreamType uint8
+
+// Stream types
+const (
+	StreamTypeMPEG1Video StreamType = 0x01
+	StreamTypeMPEG2Video StreamType = 0x02
+	StreamTypeMPEG1Audio StreamType = 0x03
+	StreamTypeMPEG2Audio StreamType = 0x04
+	StreamTypePrivate    StreamType = 0x06
+	StreamTypeAAC        StreamType = 0x0f
+	StreamTypeMPEG4Video StreamType = 0x10
+	StreamTypeAACLATM    StreamType = 0x11
+	StreamTypeH264       StreamType = 0x1b
+	StreamTypeH265       StreamType = 0x24
+	StreamTypeAC3        StreamType = 0x81
+)
+
+func (t StreamType) String() string {
+	switch t {
+	case StreamTypeMPEG1Video:
+		return "MPEG-1 Video"
+	case StreamTypeMPEG2Video:
+		return "MPEG-2 Video"
+	case StreamTypeMPEG1Audio:
+		return "MPEG-1 Audio"
+	case StreamTypeMPEG2Audio:
+		return "MPEG-2 Audio"
+	case StreamTypePrivate:
+		return "Private"
+	case StreamTypeAAC:
+		return "AAC"
+	case StreamTypeMPEG4Video:
+		return "MPEG-4 Video"
+	case StreamTypeAACLATM:
+		return "AAC LATM"
+	case StreamTypeH264:
+		return "H.264"
+	case StreamTypeH265:
+		return "H.265"
+	case StreamTypeAC3:
+		return "AC-3"
+	}
+	return fmt.Sprintf("StreamType(0x%02x)", uint8(t))
+}
+
+// IsVideo reports whether t is a video stream type.
+func (t StreamType) IsVideo() bool {
+	switch t {
+	case StreamTypeMPEG1Video, StreamTypeMPEG2Video, StreamTypeMPEG4Video, StreamTypeH264, StreamTypeH265:
+		return true
+	}
+	return false
+}
+
+// Program is an entry of the PAT.
+type Program struct {
+	Number uint16
+	PID    uint16 // PMT PID, or network PID for program 0
+}
+
+// PAT is a program association table.
+type PAT struct {
+	TransportStreamID uint16
+	Version           uint8
+	Programs          []Program
+}
+
+// Stream is an elementary stream entry of a PMT.
+type Stream struct {
+	Type        StreamType
+	PID         uint16
+	Descriptors []byte
+}
+
+// PMT is a program map table.
+type PMT struct {
+	ProgramNumber uint16
+	Version       uint8
+	PCRPID        uint16
+	Descriptors   []byte
+	Streams       []Stream
+}
+
+// section is the common long form section header.
+type section struct {
+	tableID   uint8
+	extension uint16
+	version   uint8
+	current   bool
+	body      []byte // after last_section_number, before CRC
+}
+
+func parseSection(b []byte) (*section, error) {
+	if len(b) < 3 {
+		return nil, bit.ErrShortBuffer
+	}
+	n := 3 + (int(b[1]&0x0f)<<8 | int(b[2]))
+	if n < 12 {
+		return nil, fmt.Errorf("ts: section length %d too small", n)
+	}
+	if len(b) < n {
+		return nil, bit.ErrShortBuffer
+	}
+	b = b[:n]
+	if b[1]&0x80 == 0 {
+		return nil, fmt.Errorf("ts: table 0x%02x is not a long form section", b[0])
+	}
+	if CRC32(b) != 0 {
+		return nil, ErrCRC
+	}
+	return &section{
+		tableID:   b[0],
+		extension: uint16(b[3])<<8 | uint16(b[4]),
+		version:   (b[5] >> 1) & 0x1f,
+		current:   b[5]&0x01 != 0,
+		body:      b[8 : n-4],
+	}, nil
+}
+
+// ParsePAT parses a PAT section starting at table_id.
+func ParsePAT(b []byte) (*PAT, error) {
+	s, err := parseSection(b)
+	if err != nil {
+		return nil, err
+	}
+	if s.tableID != TableIDPAT {
+		return nil, fmt.Errorf("ts: table 0x%02x is not a PAT", s.tableID)
+	}
+	pat := &PAT{TransportStreamID: s.extension, Version: s.version}
+	r := bit.NewReader(s.body)
+	for r.Left() >= 4 {
+		num := uint16(r.Read(16))
+		r.Skip(3)
+		pat.Programs = append(pat.Programs, Program{Number: num, PID: uint16(r.Read(13))})
+	}
+	return pat, nil
+}
+
+// ParsePMT parses a PMT section starting at table_id.
+func ParsePMT(b []byte) (*PMT, error) {
+	s, err := parseSection(b)
+	if err != nil {
+		return nil, err
+	}
+	if s.tableID != TableIDPMT {
+		return nil, fmt.Errorf("ts: table 0x%02x is not a PMT", s.tableID)
+	}
+	pmt := &PMT{ProgramNumber: s.extension, Version: s.version}
+	r := bit.NewReader(s.body)
+	r.Skip(3)
+	pmt.PCRPID = uint16(r.Read(13))
+	r.Skip(4)
+	if pmt.Descriptors, err = readDescriptors(r); err != nil {
+		return nil, err
+	}
+	for r.Left() >= 5 {
+		st := Stream{Type: StreamType(r.Read(8))}
+		r.Skip(3)
+		st.PID = uint16(r.Read(13))
+		r.Skip(4)
+		if st.Descriptors, err = readDescriptors(r); err != nil {
+			return nil, err
+		}
+		pmt.Streams = append(pmt.Streams, st)
+	}
+	return pmt, nil
+}
+
+func readDescriptors(r *bit.Reader) ([]byte, error) {
+	n := int(r.Read(12))
+	if n > r.Left() {
+		return nil, fmt.Errorf("ts: descriptor length %d exceeds section", n)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	return r.GetSlice(n), nil
+}
+
+var _crcTable = func() (t [256]uint32) {
+	for i := range t {
+		c := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if c&0x80000000 != 0 {
+				c = c<<1 ^ 0x04c11db7
+			} else {
+				c <<= 1
+			}
+		}
+		t[i] = c
+	}
+	return
+}()
+
+// CRC32 computes the MPEG-2 CRC of PSI sections. It is 0 over a section
+// including its CRC_32 field.
+func CRC32(b []byte) uint32 {
+	c := uint32(0xffffffff)
+	for _, v := range b {
+		c = c<<8 ^ _crcTable[byte(c>>24)^v]
+	}
+	return c
+}
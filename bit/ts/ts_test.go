@@ -0,0 +1,301 @@
+package ts_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/kakami/pkg/bit/ts"
+)
+
+// muxer builds synthetic transport streams for the tests.
+type muxer struct {
+	cc  map[uint16]uint8
+	out bytes.Buffer
+}
+
+func newMuxer() *muxer {
+	return &muxer{cc: make(map[uint16]uint8)}
+}
+
+// packet writes one packet, stuffing the adaptation field to 188 bytes.
+func (m *muxer) packet(pid uint16, pusi, randomAccess bool, payload []byte) []byte {
+	n := len(payload)
+	if n > 184 {
+		n = 184
+	}
+	b := make([]byte, 4, ts.PacketSize)
+	b[0] = ts.SyncByte
+	b[1] = byte(pid >> 8 & 0x1f)
+	if pusi {
+		b[1] |= 0x40
+	}
+	b[2] = byte(pid)
+	b[3] = 0x10 | m.cc[pid]
+	m.cc[pid] = (m.cc[pid] + 1) & 0x0f
+	if n < 184 || randomAccess {
+		if n > 182 {
+			n = 182
+		}
+		b[3] |= 0x20
+		afLen := 183 - n
+		b = append(b, byte(afLen))
+		if afLen > 0 {
+			flags := byte(0)
+			if randomAccess {
+				flags = 0x40
+			}
+			b = append(b, flags)
+			b = append(b, bytes.Repeat([]byte{0xff}, afLen-1)...)
+		}
+	}
+	b = append(b, payload[:n]...)
+	m.out.Write(b)
+	return payload[n:]
+}
+
+// section writes a PSI section with pointer_field 0.
+func (m *muxer) section(pid uint16, tableID byte, ext uint16, body []byte) {
+	s := []byte{tableID, 0xb0, 0, byte(ext >> 8), byte(ext), 0xc1, 0, 0}
+	s = append(s, body...)
+	n := len(s) - 3 + 4
+	s[1] |= byte(n >> 8)
+	s[2] = byte(n)
+	crc := ts.CRC32(s)
+	s = append(s, byte(crc>>24), byte(crc>>16), byte(crc>>8), byte(crc))
+	data := append([]byte{0}, s...)
+	for pusi := true; len(data) > 0; pusi = false {
+		data = m.packet(pid, pusi, false, data)
+	}
+}
+
+func (m *muxer) pat(programs map[uint16]uint16) {
+	var body []byte
+	for num, pid := range programs {
+		body = append(body, byte(num>>8), byte(num), 0xe0|byte(pid>>8), byte(pid))
+	}
+	m.section(0, ts.TableIDPAT, 1, body)
+}
+
+func (m *muxer) pmt(pid, program, pcrPID uint16, streams ...ts.Stream) {
+	body := []byte{0xe0 | byte(pcrPID>>8), byte(pcrPID), 0xf0, 0}
+	for _, s := range streams {
+		body = append(body, byte(s.Type), 0xe0|byte(s.PID>>8), byte(s.PID), 0xf0, byte(len(s.Descriptors)))
+		body = append(body, s.Descriptors...)
+	}
+	m.section(pid, ts.TableIDPMT, program, body)
+}
+
+func timestamp(prefix byte, v int64) []byte {
+	return []byte{
+		prefix<<4 | byte(v>>29)&0x0e | 1,
+		byte(v >> 22), byte(v>>14) | 1,
+		byte(v >> 7), byte(v<<1) | 1,
+	}
+}
+
+// pes writes a PES packet, bounded sets PES_packet_length.
+func (m *muxer) pes(pid uint16, streamID byte, pts, dts int64, bounded, randomAccess bool, data []byte) {
+	var opt []byte
+	flags := byte(0)
+	switch {
+	case dts != ts.NoPTS:
+		flags = 0xc0
+		opt = append(timestamp(3, pts), timestamp(1, dts)...)
+	case pts != ts.NoPTS:
+		flags = 0x80
+		opt = timestamp(2, pts)
+	}
+	b := []byte{0, 0, 1, streamID, 0, 0, 0x80, flags, byte(len(opt))}
+	b = append(b, opt...)
+	b = append(b, data...)
+	if bounded {
+		n := len(b) - 6
+		b[4], b[5] = byte(n>>8), byte(n)
+	}
+	for pusi := true; len(b) > 0; pusi = false {
+		b = m.packet(pid, pusi, pusi && randomAccess, b)
+	}
+}
+
+func payload(n int, seed byte) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = seed + byte(i)
+	}
+	return b
+}
+
+func readAll(t *testing.T, d *ts.Demuxer) []*ts.Frame {
+	var frames []*ts.Frame
+	for {
+		f, err := d.ReadFrame()
+		if errors.Is(err, io.EOF) {
+			return frames
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		frames = append(frames, f)
+	}
+}
+
+func program(m *muxer) {
+	m.pat(map[uint16]uint16{1: 0x1000})
+	m.pmt(0x1000, 1, 0x100,
+		ts.Stream{Type: ts.StreamTypeH264, PID: 0x100},
+		ts.Stream{Type: ts.StreamTypeAAC, PID: 0x101, Descriptors: []byte{0x0a, 0x04, 'e', 'n', 'g', 0}},
+	)
+}
+
+func Test_Demuxer(t *testing.T) {
+	m := newMuxer()
+	program(m)
+	video1, video2 := payload(1000, 1), payload(500, 2)
+	audio1, audio2 := payload(300, 3), payload(10, 4)
+	m.pes(0x100, 0xe0, 3600, 0, false, true, video1)
+	m.pes(0x101, 0xc0, 1800, ts.NoPTS, true, false, audio1)
+	m.pes(0x102, 0xe0, 0, ts.NoPTS, false, false, payload(50, 0)) // not in the PMT
+	m.pes(0x100, 0xe0, 7200, 3600, false, false, video2)
+	m.pes(0x101, 0xc0, 3720, ts.NoPTS, true, false, audio2)
+
+	d := ts.NewDemuxer(&m.out)
+	frames := readAll(t, d)
+	want := []struct {
+		pid      uint16
+		typ      ts.StreamType
+		pts, dts int64
+		random   bool
+		data     []byte
+	}{
+		{0x101, ts.StreamTypeAAC, 1800, 1800, false, audio1},
+		{0x100, ts.StreamTypeH264, 3600, 0, true, video1},
+		{0x101, ts.StreamTypeAAC, 3720, 3720, false, audio2},
+		{0x100, ts.StreamTypeH264, 7200, 3600, false, video2},
+	}
+	if len(frames) != len(want) {
+		t.Fatalf("got %d frames, want %d", len(frames), len(want))
+	}
+	for i, w := range want {
+		f := frames[i]
+		if f.PID != w.pid || f.StreamType != w.typ || f.PTS != w.pts || f.DTS != w.dts ||
+			f.RandomAccess != w.random || f.Program != 1 || f.Discontinuity || !bytes.Equal(f.Data, w.data) {
+			t.Fatalf("frame %d: got pid %x %s pts %d dts %d ra %v len %d", i, f.PID, f.StreamType, f.PTS, f.DTS, f.RandomAccess, len(f.Data))
+		}
+	}
+
+	pmt := d.PMT(1)
+	if pmt == nil || pmt.PCRPID != 0x100 || len(pmt.Streams) != 2 || string(pmt.Streams[1].Descriptors[2:5]) != "eng" {
+		t.Fatalf("pmt %+v", pmt)
+	}
+	if pat := d.PAT(); pat == nil || len(pat.Programs) != 1 || pat.Programs[0].PID != 0x1000 {
+		t.Fatalf("pat %+v", pat)
+	}
+}
+
+func Test_DemuxerContinuity(t *testing.T) {
+	m := newMuxer()
+	program(m)
+	m.pes(0x101, 0xc0, 0, ts.NoPTS, true, false, payload(400, 1))
+	start := m.out.Len()
+	m.pes(0x101, 0xc0, 1920, ts.NoPTS, true, false, payload(400, 2))
+	lost := m.out.Bytes()[start+ts.PacketSize : start+2*ts.PacketSize]
+	lost = append([]byte(nil), lost...)
+	m.pes(0x101, 0xc0, 3840, ts.NoPTS, true, false, payload(400, 3))
+
+	// drop the second packet of the second frame and duplicate a packet
+	// of the third one
+	b := m.out.Bytes()
+	stream := append([]byte(nil), b[:start+ts.PacketSize]...)
+	stream = append(stream, b[start+2*ts.PacketSize:start+4*ts.PacketSize]...)
+	stream = append(stream, b[start+3*ts.PacketSize:]...)
+	if bytes.Equal(lost, stream[start+ts.PacketSize:start+2*ts.PacketSize]) {
+		t.Fatal("fixture did not drop a packet")
+	}
+
+	frames := readAll(t, ts.NewDemuxer(bytes.NewReader(stream)))
+	if len(frames) != 2 {
+		t.Fatalf("got %d frames, want 2", len(frames))
+	}
+	if frames[0].PTS != 0 || frames[0].Discontinuity {
+		t.Fatalf("frame 0: %+v", frames[0])
+	}
+	if frames[1].PTS != 3840 || !frames[1].Discontinuity || !bytes.Equal(frames[1].Data, payload(400, 3)) {
+		t.Fatalf("frame 1: pts %d discontinuity %v", frames[1].PTS, frames[1].Discontinuity)
+	}
+}
+
+func Test_DemuxerResync(t *testing.T) {
+	m := newMuxer()
+	program(m)
+	m.pes(0x100, 0xe0, 90000, ts.NoPTS, false, true, payload(200, 1))
+	stream := append([]byte{0x00, 0x47, 0x12}, m.out.Bytes()...)
+
+	frames := readAll(t, ts.NewDemuxer(bytes.NewReader(stream)))
+	if len(frames) != 1 || frames[0].PTS != 90000 || !bytes.Equal(frames[0].Data, payload(200, 1)) {
+		t.Fatalf("got %d frames", len(frames))
+	}
+}
+
+func Test_ParsePacket(t *testing.T) {
+	b := make([]byte, ts.PacketSize)
+	b[0], b[1], b[2], b[3] = 0x47, 0x41, 0x00, 0x35
+	b[4] = 7
+	b[5] = 0x50 // random access, PCR
+	// PCR base 0x123456789 (33 bits) and extension 0x0ab
+	base, ext := int64(0x123456789), int64(0xab)
+	b[6], b[7], b[8], b[9] = byte(base>>25), byte(base>>17), byte(base>>9), byte(base>>1)
+	b[10] = byte(base<<7) | 0x7e | byte(ext>>8)
+	b[11] = byte(ext)
+	p, err := ts.ParsePacket(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.PayloadUnitStart || p.PID != 0x100 || p.ContinuityCounter != 5 || !p.HasPayload || len(p.Payload) != 176 {
+		t.Fatalf("got %+v", p)
+	}
+	if !p.Adaptation.RandomAccess || !p.Adaptation.HasPCR || p.Adaptation.PCR != base*300+ext {
+		t.Fatalf("adaptation %+v", p.Adaptation)
+	}
+
+	b[0] = 0
+	if _, err := ts.ParsePacket(b); err != ts.ErrSync {
+		t.Fatalf("got %v, want ErrSync", err)
+	}
+	if _, err := ts.ParsePacket(b[:100]); err == nil {
+		t.Fatal("expected error for short packet")
+	}
+}
+
+func Test_ParsePESHeader(t *testing.T) {
+	b := append([]byte{0, 0, 1, 0xe0, 0, 0, 0x80, 0xc0, 10}, timestamp(3, 1<<32+5)...)
+	b = append(b, timestamp(1, 1<<32)...)
+	h, err := ts.ParsePESHeader(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.StreamID != 0xe0 || h.PTS != 1<<32+5 || h.DTS != 1<<32 || h.HeaderLen != 19 {
+		t.Fatalf("got %+v", h)
+	}
+
+	if _, err := ts.ParsePESHeader([]byte{0, 0, 2, 0xe0, 0, 0}); err == nil {
+		t.Fatal("expected error for bad start code")
+	}
+}
+
+func Test_CRC32(t *testing.T) {
+	// PAT of a single program stream from ffmpeg
+	pat := []byte{0x00, 0xb0, 0x0d, 0x00, 0x01, 0xc1, 0x00, 0x00, 0x00, 0x01, 0xf0, 0x00, 0x2a, 0xb1, 0x04, 0xb2}
+	if ts.CRC32(pat) != 0 {
+		t.Fatalf("crc %08x", ts.CRC32(pat[:len(pat)-4]))
+	}
+	p, err := ts.ParsePAT(pat)
+	if err != nil || p.Programs[0] != (ts.Program{Number: 1, PID: 0x1000}) {
+		t.Fatalf("got %+v %v", p, err)
+	}
+	pat[10] = 0xe1
+	if _, err := ts.ParsePAT(pat); err != ts.ErrCRC {
+		t.Fatalf("got %v, want ErrCRC", err)
+	}
+}
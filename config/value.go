@@ -4,13 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
-	"strconv"
 	"sync/atomic"
 	"time"
 
 	"google.golang.org/protobuf/proto"
 
 	kratosjson "github.com/go-kratos/kratos/v2/encoding/json"
+
+	"github.com/kakami/pkg/convert"
 )
 
 var (
@@ -25,6 +26,7 @@ type Value interface {
 	Float() (float64, error)
 	String() (string, error)
 	Duration() (time.Duration, error)
+	Bytes() (int64, error)
 	Slice() ([]Value, error)
 	Map() (map[string]Value, error)
 	Scan(interface{}) error
@@ -41,45 +43,12 @@ func (v *atomicValue) typeAssertError() error {
 }
 
 func (v *atomicValue) Bool() (bool, error) {
-	switch val := v.Load().(type) {
-	case bool:
-		return val, nil
-	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, string:
-		return strconv.ParseBool(fmt.Sprint(val))
-	}
-	return false, v.typeAssertError()
+	return convert.Config.ToBool(v.Load())
 }
 
+// Int truncates floats, e.g. JSON numbers.
 func (v *atomicValue) Int() (int64, error) {
-	switch val := v.Load().(type) {
-	case int:
-		return int64(val), nil
-	case int8:
-		return int64(val), nil
-	case int16:
-		return int64(val), nil
-	case int32:
-		return int64(val), nil
-	case int64:
-		return val, nil
-	case uint:
-		return int64(val), nil
-	case uint8:
-		return int64(val), nil
-	case uint16:
-		return int64(val), nil
-	case uint32:
-		return int64(val), nil
-	case uint64:
-		return int64(val), nil
-	case float32:
-		return int64(val), nil
-	case float64:
-		return int64(val), nil
-	case string:
-		return strconv.ParseInt(val, 10, 64)
-	}
-	return 0, v.typeAssertError()
+	return convert.Config.ToInt64(v.Load())
 }
 
 func (v *atomicValue) Slice() ([]Value, error) {
@@ -97,9 +66,9 @@ func (v *atomicValue) Slice() ([]Value, error) {
 }
 
 func (v *atomicValue) Map() (map[string]Value, error) {
-	vals, ok := v.Load().(map[string]interface{})
-	if !ok {
-		return nil, v.typeAssertError()
+	vals, err := convert.Config.ToStringMap(v.Load())
+	if err != nil {
+		return nil, err
 	}
 	m := make(map[string]Value, len(vals))
 	for key, val := range vals {
@@ -111,57 +80,25 @@ func (v *atomicValue) Map() (map[string]Value, error) {
 }
 
 func (v *atomicValue) Float() (float64, error) {
-	switch val := v.Load().(type) {
-	case int:
-		return float64(val), nil
-	case int8:
-		return float64(val), nil
-	case int16:
-		return float64(val), nil
-	case int32:
-		return float64(val), nil
-	case int64:
-		return float64(val), nil
-	case uint:
-		return float64(val), nil
-	case uint8:
-		return float64(val), nil
-	case uint16:
-		return float64(val), nil
-	case uint32:
-		return float64(val), nil
-	case uint64:
-		return float64(val), nil
-	case float32:
-		return float64(val), nil
-	case float64:
-		return val, nil
-	case string:
-		return strconv.ParseFloat(val, 64)
-	}
-	return 0.0, v.typeAssertError()
+	return convert.Config.ToFloat64(v.Load())
 }
 
 func (v *atomicValue) String() (string, error) {
-	switch val := v.Load().(type) {
-	case string:
-		return val, nil
-	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
-		return fmt.Sprint(val), nil
-	case []byte:
-		return string(val), nil
-	case fmt.Stringer:
-		return val.String(), nil
-	}
-	return "", v.typeAssertError()
+	return convert.Config.ToString(v.Load())
 }
 
+// Duration returns numbers and unitless strings as nanoseconds, other
+// strings may be like "1d2h".
 func (v *atomicValue) Duration() (time.Duration, error) {
-	val, err := v.Int()
-	if err != nil {
-		return 0, err
+	return convert.Config.ToDuration(v.Load())
+}
+
+// Bytes returns a byte size, strings may carry a unit like "64MiB".
+func (v *atomicValue) Bytes() (int64, error) {
+	if val, ok := v.Load().(string); ok {
+		return convert.ParseBytes(val)
 	}
-	return time.Duration(val), nil
+	return v.Int()
 }
 
 func (v *atomicValue) Scan(obj interface{}) error {
@@ -183,6 +120,7 @@ func (v errValue) Bool() (bool, error)              { return false, v.err }
 func (v errValue) Int() (int64, error)              { return 0, v.err }
 func (v errValue) Float() (float64, error)          { return 0.0, v.err }
 func (v errValue) Duration() (time.Duration, error) { return 0, v.err }
+func (v errValue) Bytes() (int64, error)            { return 0, v.err }
 func (v errValue) String() (string, error)          { return "", v.err }
 func (v errValue) Scan(interface{}) error           { return v.err }
 func (v errValue) Load() interface{}                { return nil }
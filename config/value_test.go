@@ -69,6 +69,21 @@ func TestAtomicValue_Int(t *testing.T) {
 	}
 }
 
+func TestAtomicValue_IntTruncate(t *testing.T) {
+	vlist := map[interface{}]int64{float64(1.5): 1, float32(-2.9): -2, float64(123123.9): 123123}
+	for x, want := range vlist {
+		v := atomicValue{}
+		v.Store(x)
+		b, err := v.Int()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b != want {
+			t.Fatalf("%v: got %d, want %d", x, b, want)
+		}
+	}
+}
+
 func TestAtomicValue_Float(t *testing.T) {
 	vlist := []interface{}{"123123.1", 123123.1}
 	for _, x := range vlist {
@@ -156,6 +171,59 @@ func TestAtomicValue_Duration(t *testing.T) {
 	}
 }
 
+func TestAtomicValue_DurationUnitless(t *testing.T) {
+	vlist := map[interface{}]time.Duration{
+		"5":          5,
+		float64(5.9): 5,
+		"1d2h":       26 * time.Hour,
+		"1.5s":       1500 * time.Millisecond,
+	}
+	for x, want := range vlist {
+		v := atomicValue{}
+		v.Store(x)
+		b, err := v.Duration()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b != want {
+			t.Fatalf("%v: got %v, want %v", x, b, want)
+		}
+	}
+
+	v := atomicValue{}
+	v.Store("5 parsecs")
+	if _, err := v.Duration(); err == nil {
+		t.Fatal("err is nil")
+	}
+}
+
+func TestAtomicValue_Bytes(t *testing.T) {
+	vlist := map[interface{}]int64{
+		"64MiB":      64 << 20,
+		"1.5 GB":     1500000000,
+		"512":        512,
+		int(4096):    4096,
+		float64(1e3): 1000,
+	}
+	for x, want := range vlist {
+		v := atomicValue{}
+		v.Store(x)
+		b, err := v.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b != want {
+			t.Fatalf("%v: got %d, want %d", x, b, want)
+		}
+	}
+
+	v := atomicValue{}
+	v.Store("lots")
+	if _, err := v.Bytes(); err == nil {
+		t.Fatal("err is nil")
+	}
+}
+
 func TestAtomicValue_Slice(t *testing.T) {
 	vlist := []interface{}{int64(5)}
 	v := atomicValue{}
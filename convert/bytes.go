@@ -1,21 +1,7 @@
 package convert
 
-import (
-	"bytes"
-	"io/ioutil"
-
-	"golang.org/x/text/encoding/simplifiedchinese"
-	"golang.org/x/text/transform"
-)
-
 // DecodeGBK ...
 // convert gbk to utf-8
 func DecodeGBK(s []byte) ([]byte, error) {
-	I := bytes.NewReader(s)
-	O := transform.NewReader(I, simplifiedchinese.GBK.NewDecoder())
-	d, e := ioutil.ReadAll(O)
-	if e != nil {
-		return nil, e
-	}
-	return d, nil
+	return Decode(s, GBK)
 }
@@ -0,0 +1,390 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mode selects how strictly values are coerced.
+type Mode int
+
+const (
+	// Strict only converts without loss: floats must be integral to become
+	// integers, strings must parse exactly and bools are 0 or 1.
+	Strict Mode = iota
+	// Lenient also trims strings, truncates floats, accepts yes/no/on/off
+	// for bools, hexadecimal numbers and comma separated lists.
+	Lenient
+	// Config is Strict, except that floats are truncated to integers, as
+	// decoded JSON numbers are float64, and unitless duration strings are
+	// nanoseconds. config.Value converts with it.
+	Config
+)
+
+// ErrUnsupported is the cause of a CastError for values of a type that
+// cannot be converted at all.
+var ErrUnsupported = errors.New("unsupported type")
+
+// CastError is returned by the To functions.
+type CastError struct {
+	Value interface{}
+	To    string
+	Err   error // ErrUnsupported, strconv.ErrSyntax or strconv.ErrRange
+}
+
+func (e *CastError) Error() string {
+	return fmt.Sprintf("convert: cannot convert %#v (%T) to %s: %v", e.Value, e.Value, e.To, e.Err)
+}
+
+func (e *CastError) Unwrap() error {
+	return e.Err
+}
+
+func castError(v interface{}, to string, err error) error {
+	var ne *strconv.NumError
+	if errors.As(err, &ne) {
+		err = ne.Err
+	}
+	return &CastError{Value: v, To: to, Err: err}
+}
+
+// ToInt64 converts v leniently, see Mode.ToInt64.
+func ToInt64(v interface{}) (int64, error) { return Lenient.ToInt64(v) }
+
+// ToFloat64 converts v leniently, see Mode.ToFloat64.
+func ToFloat64(v interface{}) (float64, error) { return Lenient.ToFloat64(v) }
+
+// ToBool converts v leniently, see Mode.ToBool.
+func ToBool(v interface{}) (bool, error) { return Lenient.ToBool(v) }
+
+// ToString converts v, see Mode.ToString.
+func ToString(v interface{}) (string, error) { return Lenient.ToString(v) }
+
+// ToDuration converts v leniently, see Mode.ToDuration.
+func ToDuration(v interface{}) (time.Duration, error) { return Lenient.ToDuration(v) }
+
+// ToStringSlice converts v leniently, see Mode.ToStringSlice.
+func ToStringSlice(v interface{}) ([]string, error) { return Lenient.ToStringSlice(v) }
+
+// ToStringMap converts v leniently, see Mode.ToStringMap.
+func ToStringMap(v interface{}) (map[string]interface{}, error) { return Lenient.ToStringMap(v) }
+
+// number classifies numeric values of any integer, float or named
+// numeric type.
+func number(v interface{}) (i int64, u uint64, f float64, kind reflect.Kind, ok bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), 0, 0, reflect.Int64, true
+	case int64:
+		return n, 0, 0, reflect.Int64, true
+	case float64:
+		return 0, 0, n, reflect.Float64, true
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i, 0, 0, reflect.Int64, true
+		}
+		if f, err := n.Float64(); err == nil {
+			return 0, 0, f, reflect.Float64, true
+		}
+		return 0, 0, 0, reflect.Invalid, false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), 0, 0, reflect.Int64, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return 0, rv.Uint(), 0, reflect.Uint64, true
+	case reflect.Float32, reflect.Float64:
+		return 0, 0, rv.Float(), reflect.Float64, true
+	}
+	return 0, 0, 0, reflect.Invalid, false
+}
+
+// ToInt64 converts integers, floats, numeric strings and, in Lenient mode,
+// bools. Lenient strings may be hexadecimal ("0x10"), contain underscores
+// or be floats, which are truncated like float values.
+func (m Mode) ToInt64(v interface{}) (int64, error) {
+	const to = "int64"
+	if i, u, f, kind, ok := number(v); ok {
+		switch kind {
+		case reflect.Int64:
+			return i, nil
+		case reflect.Uint64:
+			if u > math.MaxInt64 {
+				return 0, castError(v, to, strconv.ErrRange)
+			}
+			return int64(u), nil
+		}
+		if math.IsNaN(f) || f >= math.MaxInt64 || f < math.MinInt64 {
+			return 0, castError(v, to, strconv.ErrRange)
+		}
+		if m == Strict && f != math.Trunc(f) {
+			return 0, castError(v, to, strconv.ErrSyntax)
+		}
+		return int64(f), nil
+	}
+	switch val := v.(type) {
+	case string:
+		if m != Lenient {
+			i, err := strconv.ParseInt(val, 10, 64)
+			if err != nil {
+				return 0, castError(v, to, err)
+			}
+			return i, nil
+		}
+		s := strings.TrimSpace(val)
+		if i, err := strconv.ParseInt(s, 0, 64); err == nil {
+			return i, nil
+		} else if errors.Is(err, strconv.ErrRange) {
+			return 0, castError(v, to, err)
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, castError(v, to, err)
+		}
+		return Lenient.ToInt64(f)
+	case []byte:
+		return m.ToInt64(string(val))
+	case bool:
+		if m == Lenient {
+			if val {
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	return 0, castError(v, to, ErrUnsupported)
+}
+
+// ToFloat64 converts numbers, numeric strings and, in Lenient mode, bools.
+func (m Mode) ToFloat64(v interface{}) (float64, error) {
+	const to = "float64"
+	if i, u, f, kind, ok := number(v); ok {
+		switch kind {
+		case reflect.Int64:
+			return float64(i), nil
+		case reflect.Uint64:
+			return float64(u), nil
+		}
+		return f, nil
+	}
+	switch val := v.(type) {
+	case string:
+		s := val
+		if m == Lenient {
+			s = strings.TrimSpace(s)
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, castError(v, to, err)
+		}
+		return f, nil
+	case []byte:
+		return m.ToFloat64(string(val))
+	case bool:
+		if m == Lenient {
+			if val {
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	return 0, castError(v, to, ErrUnsupported)
+}
+
+// ToBool converts bools, the numbers 0 and 1 and strings accepted by
+// strconv.ParseBool. Lenient mode also accepts yes/no, y/n and on/off in
+// any case and surrounding space.
+func (m Mode) ToBool(v interface{}) (bool, error) {
+	const to = "bool"
+	switch val := v.(type) {
+	case bool:
+		return val, nil
+	case string:
+		s := val
+		if m == Lenient {
+			switch strings.ToLower(strings.TrimSpace(s)) {
+			case "yes", "y", "on":
+				return true, nil
+			case "no", "n", "off":
+				return false, nil
+			}
+			s = strings.TrimSpace(s)
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil && m == Lenient {
+			b, err = strconv.ParseBool(strings.ToLower(s))
+		}
+		if err != nil {
+			return false, castError(v, to, err)
+		}
+		return b, nil
+	case []byte:
+		return m.ToBool(string(val))
+	}
+	if i, u, f, kind, ok := number(v); ok {
+		switch {
+		case kind == reflect.Int64 && (i == 0 || i == 1):
+			return i == 1, nil
+		case kind == reflect.Uint64 && u <= 1:
+			return u == 1, nil
+		case kind == reflect.Float64 && (f == 0 || f == 1):
+			return f == 1, nil
+		}
+		return false, castError(v, to, strconv.ErrSyntax)
+	}
+	return false, castError(v, to, ErrUnsupported)
+}
+
+// ToString converts strings, byte slices, numbers, bools, durations and
+// fmt.Stringer values. Both modes behave the same, formatting is lossless.
+func (m Mode) ToString(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case []byte:
+		return string(val), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case json.Number:
+		return val.String(), nil
+	case float32:
+		return strconv.FormatFloat(float64(val), 'g', -1, 32), nil
+	case fmt.Stringer:
+		return val.String(), nil
+	case error:
+		return val.Error(), nil
+	}
+	if i, u, f, kind, ok := number(v); ok {
+		switch kind {
+		case reflect.Int64:
+			return strconv.FormatInt(i, 10), nil
+		case reflect.Uint64:
+			return strconv.FormatUint(u, 10), nil
+		}
+		return strconv.FormatFloat(f, 'g', -1, 64), nil
+	}
+	return "", castError(v, "string", ErrUnsupported)
+}
+
+// ToDuration converts durations, integers as nanoseconds and strings
+// accepted by ParseDuration, including days like "1d2h". Lenient and
+// Config mode also read floats and unitless numeric strings as nanoseconds.
+func (m Mode) ToDuration(v interface{}) (time.Duration, error) {
+	const to = "time.Duration"
+	switch val := v.(type) {
+	case time.Duration:
+		return val, nil
+	case string:
+		s := val
+		if m == Lenient {
+			s = strings.TrimSpace(s)
+		}
+		if m != Strict {
+			if n, err := m.ToInt64(s); err == nil {
+				return time.Duration(n), nil
+			}
+		}
+		d, err := ParseDuration(s)
+		if err != nil {
+			return 0, castError(v, to, strconv.ErrSyntax)
+		}
+		return d, nil
+	case []byte:
+		return m.ToDuration(string(val))
+	}
+	if _, _, _, _, ok := number(v); ok {
+		n, err := m.ToInt64(v)
+		if err != nil {
+			return 0, castError(v, to, errors.Unwrap(err))
+		}
+		return time.Duration(n), nil
+	}
+	return 0, castError(v, to, ErrUnsupported)
+}
+
+// ToStringSlice converts slices and arrays whose elements convert with
+// ToString. Lenient mode also splits a string on commas, trimming space
+// around the items.
+func (m Mode) ToStringSlice(v interface{}) ([]string, error) {
+	const to = "[]string"
+	switch val := v.(type) {
+	case []string:
+		return val, nil
+	case string:
+		if m == Lenient {
+			if strings.TrimSpace(val) == "" {
+				return []string{}, nil
+			}
+			items := strings.Split(val, ",")
+			for i := range items {
+				items[i] = strings.TrimSpace(items[i])
+			}
+			return items, nil
+		}
+		return nil, castError(v, to, ErrUnsupported)
+	case []byte:
+		return nil, castError(v, to, ErrUnsupported)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, castError(v, to, ErrUnsupported)
+	}
+	out := make([]string, rv.Len())
+	for i := range out {
+		s, err := m.ToString(rv.Index(i).Interface())
+		if err != nil {
+			return nil, castError(v, to, errors.Unwrap(err))
+		}
+		out[i] = s
+	}
+	return out, nil
+}
+
+// ToStringMap converts maps with string keys, as well as the
+// map[interface{}]interface{} YAML decoders produce when all keys are
+// strings. Lenient mode converts any key with ToString and also parses
+// JSON objects.
+func (m Mode) ToStringMap(v interface{}) (map[string]interface{}, error) {
+	const to = "map[string]interface{}"
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return val, nil
+	case string:
+		if m == Lenient {
+			out := make(map[string]interface{})
+			if err := json.Unmarshal([]byte(val), &out); err != nil {
+				return nil, castError(v, to, strconv.ErrSyntax)
+			}
+			return out, nil
+		}
+		return nil, castError(v, to, ErrUnsupported)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map {
+		return nil, castError(v, to, ErrUnsupported)
+	}
+	out := make(map[string]interface{}, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		k := iter.Key().Interface()
+		key, ok := k.(string)
+		if !ok {
+			var err error
+			if m != Lenient {
+				return nil, castError(v, to, ErrUnsupported)
+			}
+			if key, err = m.ToString(k); err != nil {
+				return nil, castError(v, to, ErrUnsupported)
+			}
+		}
+		out[key] = iter.Value().Interface()
+	}
+	return out, nil
+}
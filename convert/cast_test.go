@@ -0,0 +1,266 @@
+package convert_test
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kakami/pkg/convert"
+)
+
+type myInt int16
+
+func Test_ToInt64(t *testing.T) {
+	cases := []struct {
+		in      interface{}
+		strict  int64
+		lenient int64
+		err     error // strict error, lenient succeeds unless errBoth
+		errBoth bool
+	}{
+		{in: 42, strict: 42, lenient: 42},
+		{in: int8(-3), strict: -3, lenient: -3},
+		{in: myInt(7), strict: 7, lenient: 7},
+		{in: uint32(9), strict: 9, lenient: 9},
+		{in: float64(123123), strict: 123123, lenient: 123123},
+		{in: "123", strict: 123, lenient: 123},
+		{in: []byte("-5"), strict: -5, lenient: -5},
+		{in: 1.9, lenient: 1, err: strconv.ErrSyntax},
+		{in: " 12 ", lenient: 12, err: strconv.ErrSyntax},
+		{in: "0x10", lenient: 16, err: strconv.ErrSyntax},
+		{in: "1_000", lenient: 1000, err: strconv.ErrSyntax},
+		{in: "2.5", lenient: 2, err: strconv.ErrSyntax},
+		{in: true, lenient: 1, err: convert.ErrUnsupported},
+		{in: "bbb", err: strconv.ErrSyntax, errBoth: true},
+		{in: uint64(math.MaxUint64), err: strconv.ErrRange, errBoth: true},
+		{in: "9223372036854775808", err: strconv.ErrRange, errBoth: true},
+		{in: math.NaN(), err: strconv.ErrRange, errBoth: true},
+		{in: struct{}{}, err: convert.ErrUnsupported, errBoth: true},
+		{in: nil, err: convert.ErrUnsupported, errBoth: true},
+	}
+	for _, c := range cases {
+		got, err := convert.Strict.ToInt64(c.in)
+		if c.err != nil {
+			var ce *convert.CastError
+			if !errors.As(err, &ce) || !errors.Is(err, c.err) {
+				t.Errorf("strict %#v: got err %v, want %v", c.in, err, c.err)
+			}
+		} else if err != nil || got != c.strict {
+			t.Errorf("strict %#v: got %d %v, want %d", c.in, got, err, c.strict)
+		}
+
+		got, err = convert.ToInt64(c.in)
+		if c.errBoth {
+			if !errors.Is(err, c.err) {
+				t.Errorf("lenient %#v: got err %v, want %v", c.in, err, c.err)
+			}
+		} else if err != nil || got != c.lenient {
+			t.Errorf("lenient %#v: got %d %v, want %d", c.in, got, err, c.lenient)
+		}
+	}
+}
+
+func Test_ToInt64Config(t *testing.T) {
+	ok := map[interface{}]int64{1.9: 1, float32(-2.9): -2, "123": 123, 7: 7}
+	for in, want := range ok {
+		if got, err := convert.Config.ToInt64(in); err != nil || got != want {
+			t.Errorf("%#v: got %d %v, want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []interface{}{" 12 ", "0x10", "2.5", true} {
+		if _, err := convert.Config.ToInt64(in); err == nil {
+			t.Errorf("%#v: expected error", in)
+		}
+	}
+}
+
+func Test_ToFloat64(t *testing.T) {
+	for _, in := range []interface{}{1.5, float32(1.5), "1.5", []byte("1.5"), "15e-1"} {
+		if f, err := convert.Strict.ToFloat64(in); err != nil || f != 1.5 {
+			t.Errorf("%#v: got %v %v", in, f, err)
+		}
+	}
+	if f, err := convert.Strict.ToFloat64(uint8(3)); err != nil || f != 3 {
+		t.Errorf("uint8: got %v %v", f, err)
+	}
+	if _, err := convert.Strict.ToFloat64(" 1.5"); !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("strict space: got %v", err)
+	}
+	if f, err := convert.ToFloat64(" 1.5"); err != nil || f != 1.5 {
+		t.Errorf("lenient space: got %v %v", f, err)
+	}
+	if _, err := convert.Strict.ToFloat64(true); !errors.Is(err, convert.ErrUnsupported) {
+		t.Errorf("strict bool: got %v", err)
+	}
+	if f, err := convert.ToFloat64(true); err != nil || f != 1 {
+		t.Errorf("lenient bool: got %v %v", f, err)
+	}
+}
+
+func Test_ToBool(t *testing.T) {
+	both := map[interface{}]bool{
+		true: true, "1": true, "t": true, "TRUE": true, 1: true, uint(1): true, 1.0: true,
+		false: false, "0": false, "F": false, "False": false, 0: false, int32(0): false,
+	}
+	for in, want := range both {
+		for _, m := range []convert.Mode{convert.Strict, convert.Lenient} {
+			if b, err := m.ToBool(in); err != nil || b != want {
+				t.Errorf("mode %d %#v: got %v %v, want %v", m, in, b, err, want)
+			}
+		}
+	}
+
+	lenient := map[string]bool{"yes": true, " On ": true, "Y": true, "tRuE": true, "no": false, "OFF": false, "n": false}
+	for in, want := range lenient {
+		if _, err := convert.Strict.ToBool(in); !errors.Is(err, strconv.ErrSyntax) {
+			t.Errorf("strict %q: got %v", in, err)
+		}
+		if b, err := convert.ToBool(in); err != nil || b != want {
+			t.Errorf("lenient %q: got %v %v, want %v", in, b, err, want)
+		}
+	}
+
+	for _, in := range []interface{}{"bbb", "-1", 2, 0.5} {
+		if _, err := convert.ToBool(in); !errors.Is(err, strconv.ErrSyntax) {
+			t.Errorf("%#v: got %v", in, err)
+		}
+	}
+	if _, err := convert.ToBool([]int{1}); !errors.Is(err, convert.ErrUnsupported) {
+		t.Errorf("slice: got %v", err)
+	}
+}
+
+func Test_ToString(t *testing.T) {
+	cases := map[interface{}]string{
+		"s":                    "s",
+		true:                   "true",
+		-12:                    "-12",
+		uint64(math.MaxUint64): "18446744073709551615",
+		1.0:                    "1",
+		0.25:                   "0.25",
+		float32(0.1):           "0.1",
+		time.Second:            "1s",
+	}
+	for in, want := range cases {
+		if s, err := convert.Strict.ToString(in); err != nil || s != want {
+			t.Errorf("%#v: got %q %v, want %q", in, s, err, want)
+		}
+	}
+	if s, err := convert.ToString([]byte("raw")); err != nil || s != "raw" {
+		t.Errorf("bytes: got %q %v", s, err)
+	}
+	if s, err := convert.ToString(errors.New("boom")); err != nil || s != "boom" {
+		t.Errorf("error: got %q %v", s, err)
+	}
+	if _, err := convert.ToString(struct{}{}); !errors.Is(err, convert.ErrUnsupported) {
+		t.Errorf("struct: got %v", err)
+	}
+}
+
+func Test_ToDuration(t *testing.T) {
+	both := map[interface{}]time.Duration{
+		time.Minute:   time.Minute,
+		int64(5):      5,
+		"1.5s":        1500 * time.Millisecond,
+		"1d2h":        26 * time.Hour,
+		float64(1000): time.Microsecond,
+	}
+	for in, want := range both {
+		for _, m := range []convert.Mode{convert.Strict, convert.Lenient} {
+			if d, err := m.ToDuration(in); err != nil || d != want {
+				t.Errorf("mode %d %#v: got %v %v, want %v", m, in, d, err, want)
+			}
+		}
+	}
+
+	if _, err := convert.Strict.ToDuration("100"); !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("strict unitless: got %v", err)
+	}
+	if d, err := convert.ToDuration(" 100 "); err != nil || d != 100 {
+		t.Errorf("lenient unitless: got %v %v", d, err)
+	}
+	if _, err := convert.Strict.ToDuration(2.5); !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("strict fraction: got %v", err)
+	}
+	if _, err := convert.ToDuration("soon"); !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("invalid: got %v", err)
+	}
+	if d, err := convert.Config.ToDuration("100"); err != nil || d != 100 {
+		t.Errorf("config unitless: got %v %v", d, err)
+	}
+	if d, err := convert.Config.ToDuration(2.5); err != nil || d != 2 {
+		t.Errorf("config fraction: got %v %v", d, err)
+	}
+	if _, err := convert.Config.ToDuration(" 100 "); !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("config space: got %v", err)
+	}
+	var ce *convert.CastError
+	if _, err := convert.ToDuration(true); !errors.As(err, &ce) || ce.To != "time.Duration" {
+		t.Errorf("bool: got %v", err)
+	}
+}
+
+func Test_ToStringSlice(t *testing.T) {
+	want := []string{"a", "1", "true"}
+	for _, m := range []convert.Mode{convert.Strict, convert.Lenient} {
+		got, err := m.ToStringSlice([]interface{}{"a", 1, true})
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("mode %d: got %v %v", m, got, err)
+		}
+		got, err = m.ToStringSlice([2]int{1, 2})
+		if err != nil || !reflect.DeepEqual(got, []string{"1", "2"}) {
+			t.Errorf("mode %d array: got %v %v", m, got, err)
+		}
+	}
+
+	if _, err := convert.Strict.ToStringSlice("a,b"); !errors.Is(err, convert.ErrUnsupported) {
+		t.Errorf("strict string: got %v", err)
+	}
+	got, err := convert.ToStringSlice(" a, b ,c")
+	if err != nil || !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("lenient string: got %v %v", got, err)
+	}
+	if got, err := convert.ToStringSlice(""); err != nil || len(got) != 0 {
+		t.Errorf("lenient empty: got %v %v", got, err)
+	}
+	if _, err := convert.ToStringSlice([]interface{}{"a", struct{}{}}); !errors.Is(err, convert.ErrUnsupported) {
+		t.Errorf("bad element: got %v", err)
+	}
+}
+
+func Test_ToStringMap(t *testing.T) {
+	yaml := map[interface{}]interface{}{"a": 1, "b": "x"}
+	want := map[string]interface{}{"a": 1, "b": "x"}
+	for _, m := range []convert.Mode{convert.Strict, convert.Lenient} {
+		got, err := m.ToStringMap(yaml)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("mode %d: got %v %v", m, got, err)
+		}
+		got, err = m.ToStringMap(map[string]int{"a": 1})
+		if err != nil || !reflect.DeepEqual(got, map[string]interface{}{"a": 1}) {
+			t.Errorf("mode %d typed: got %v %v", m, got, err)
+		}
+	}
+
+	mixed := map[interface{}]interface{}{1: "one"}
+	if _, err := convert.Strict.ToStringMap(mixed); !errors.Is(err, convert.ErrUnsupported) {
+		t.Errorf("strict key: got %v", err)
+	}
+	if got, err := convert.ToStringMap(mixed); err != nil || got["1"] != "one" {
+		t.Errorf("lenient key: got %v %v", got, err)
+	}
+
+	if _, err := convert.Strict.ToStringMap(`{"a":1}`); !errors.Is(err, convert.ErrUnsupported) {
+		t.Errorf("strict json: got %v", err)
+	}
+	got, err := convert.ToStringMap(`{"a":1}`)
+	if err != nil || got["a"] != float64(1) {
+		t.Errorf("lenient json: got %v %v", got, err)
+	}
+	if _, err := convert.ToStringMap(`{`); !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("bad json: got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Charset names
+const (
+	UTF8      = "UTF-8"
+	UTF16     = "UTF-16" // big-endian unless a BOM says otherwise
+	UTF16BE   = "UTF-16BE"
+	UTF16LE   = "UTF-16LE"
+	GBK       = "GBK"
+	GB18030   = "GB18030"
+	HZGB2312  = "HZ-GB-2312"
+	Big5      = "Big5"
+	ShiftJIS  = "Shift_JIS"
+	EUCJP     = "EUC-JP"
+	ISO2022JP = "ISO-2022-JP"
+	EUCKR     = "EUC-KR"
+	Latin1    = "ISO-8859-1"
+	Win1252   = "windows-1252"
+)
+
+// ErrUnknownCharset is returned for charset names not in the registry.
+var ErrUnknownCharset = errors.New("convert: unknown charset")
+
+type charset struct {
+	name string
+	enc  encoding.Encoding
+}
+
+var (
+	_charsetMu sync.RWMutex
+	_charsets  = make(map[string]charset) // normalized name or alias
+)
+
+func init() {
+	Register(UTF8, unicode.UTF8, "utf8")
+	Register(UTF16, unicode.UTF16(unicode.BigEndian, unicode.UseBOM), "ucs2")
+	Register(UTF16BE, unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM))
+	Register(UTF16LE, unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM))
+	Register(GBK, simplifiedchinese.GBK, "gb2312", "cp936", "euccn", "windows936")
+	Register(GB18030, simplifiedchinese.GB18030)
+	Register(HZGB2312, simplifiedchinese.HZGB2312, "hz")
+	Register(Big5, traditionalchinese.Big5, "cp950", "big5hkscs")
+	Register(ShiftJIS, japanese.ShiftJIS, "sjis", "cp932", "windows31j", "mskanji")
+	Register(EUCJP, japanese.EUCJP)
+	Register(ISO2022JP, japanese.ISO2022JP)
+	Register(EUCKR, korean.EUCKR, "cp949", "uhc", "ksc56011987")
+	Register(Latin1, charmap.ISO8859_1, "latin1", "l1")
+	Register(Win1252, charmap.Windows1252, "cp1252")
+}
+
+// normalize folds case and drops separators, so "Shift-JIS", "shift_jis"
+// and "SHIFTJIS" are the same name.
+func normalize(name string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(strings.TrimSpace(name)) {
+		if c != '-' && c != '_' && c != ' ' && c != '.' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// Register adds or replaces a charset under name and its aliases.
+func Register(name string, enc encoding.Encoding, aliases ...string) {
+	_charsetMu.Lock()
+	defer _charsetMu.Unlock()
+	cs := charset{name: name, enc: enc}
+	_charsets[normalize(name)] = cs
+	for _, alias := range aliases {
+		_charsets[normalize(alias)] = cs
+	}
+}
+
+func lookup(name string) (charset, error) {
+	_charsetMu.RLock()
+	cs, ok := _charsets[normalize(name)]
+	_charsetMu.RUnlock()
+	if !ok {
+		return cs, fmt.Errorf("%w %q", ErrUnknownCharset, name)
+	}
+	return cs, nil
+}
+
+// Lookup returns the encoding registered for a charset name or alias.
+func Lookup(name string) (encoding.Encoding, error) {
+	cs, err := lookup(name)
+	return cs.enc, err
+}
+
+// CanonicalName returns the registered name of a charset alias.
+func CanonicalName(name string) (string, error) {
+	cs, err := lookup(name)
+	return cs.name, err
+}
+
+// Charsets returns the registered charset names, without aliases.
+func Charsets() []string {
+	_charsetMu.RLock()
+	defer _charsetMu.RUnlock()
+	seen := make(map[string]bool)
+	var names []string
+	for _, cs := range _charsets {
+		if !seen[cs.name] {
+			seen[cs.name] = true
+			names = append(names, cs.name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Decode converts data in charset to UTF-8. Invalid sequences become
+// U+FFFD.
+func Decode(data []byte, charset string) ([]byte, error) {
+	enc, err := Lookup(charset)
+	if err != nil {
+		return nil, err
+	}
+	return enc.NewDecoder().Bytes(data)
+}
+
+// Encode converts UTF-8 data to charset. It fails on characters the
+// charset cannot represent.
+func Encode(data []byte, charset string) ([]byte, error) {
+	enc, err := Lookup(charset)
+	if err != nil {
+		return nil, err
+	}
+	return enc.NewEncoder().Bytes(data)
+}
@@ -0,0 +1,107 @@
+package convert_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/kakami/pkg/convert"
+)
+
+var _samples = []struct {
+	charset string
+	text    string
+}{
+	{convert.GB18030, "我们在这个国家里生活了很多年，大家都说这是一个好地方。"},
+	{convert.Big5, "我們在這個國家裡生活了很多年，大家都說這是一個好地方。"},
+	{convert.ShiftJIS, "私たちはこの国で長い間生活しています。日本の天気はとても良いです。"},
+	{convert.EUCKR, "우리는 이 나라에서 오랫동안 살았습니다. 모두가 좋은 곳이라고 말합니다."},
+}
+
+func Test_EncodeDecode(t *testing.T) {
+	for _, s := range _samples {
+		b, err := convert.Encode([]byte(s.text), s.charset)
+		if err != nil {
+			t.Fatalf("%s: %v", s.charset, err)
+		}
+		if bytes.Equal(b, []byte(s.text)) {
+			t.Fatalf("%s: encoding did not change the text", s.charset)
+		}
+		d, err := convert.Decode(b, s.charset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(d) != s.text {
+			t.Fatalf("%s: got %q", s.charset, d)
+		}
+	}
+
+	b, _ := convert.Encode([]byte("héllo"), "utf-16le")
+	if !bytes.Equal(b, []byte{'h', 0, 0xe9, 0, 'l', 0, 'l', 0, 'o', 0}) {
+		t.Fatalf("utf-16le %x", b)
+	}
+	d, _ := convert.Decode(append([]byte{0xff, 0xfe}, b...), convert.UTF16)
+	if string(d) != "héllo" {
+		t.Fatalf("utf-16 with BOM %q", d)
+	}
+
+	gbk, _ := convert.Encode([]byte("中文"), "gb2312")
+	d, _ = convert.DecodeGBK(gbk)
+	if string(d) != "中文" {
+		t.Fatalf("gbk %q", d)
+	}
+
+	if _, err := convert.Encode([]byte("한국어"), convert.Big5); err == nil {
+		t.Fatal("expected error for unrepresentable characters")
+	}
+	if _, err := convert.Decode(nil, "klingon"); !errors.Is(err, convert.ErrUnknownCharset) {
+		t.Fatalf("got %v, want ErrUnknownCharset", err)
+	}
+}
+
+func Test_CharsetNames(t *testing.T) {
+	for alias, name := range map[string]string{
+		"shift-jis": convert.ShiftJIS,
+		"SJIS":      convert.ShiftJIS,
+		"utf8":      convert.UTF8,
+		"cp936":     convert.GBK,
+		"euc_kr":    convert.EUCKR,
+		"Big-5":     convert.Big5,
+	} {
+		got, err := convert.CanonicalName(alias)
+		if err != nil || got != name {
+			t.Errorf("%s: got %q %v, want %q", alias, got, err, name)
+		}
+	}
+	names := convert.Charsets()
+	if len(names) < 10 {
+		t.Fatalf("charsets %v", names)
+	}
+}
+
+func Test_Detect(t *testing.T) {
+	for _, s := range _samples {
+		b, _ := convert.Encode([]byte(s.text), s.charset)
+		name, confidence := convert.Detect(b)
+		if name != s.charset || confidence < 0.5 {
+			t.Errorf("%s: detected %s (%.2f)", s.charset, name, confidence)
+		}
+	}
+
+	cases := []struct {
+		data []byte
+		name string
+	}{
+		{[]byte("plain ascii"), convert.UTF8},
+		{[]byte("naïve café 中文"), convert.UTF8},
+		{[]byte{0xef, 0xbb, 0xbf, 'a'}, convert.UTF8},
+		{[]byte{0xff, 0xfe, 'a', 0}, convert.UTF16LE},
+		{[]byte{'h', 0, 'e', 0, 'l', 0, 'l', 0, 'o', 0}, convert.UTF16LE},
+		{[]byte{0, 'h', 0, 'e', 0, 'l', 0, 'l', 0, 'o'}, convert.UTF16BE},
+	}
+	for _, c := range cases {
+		if name, confidence := convert.Detect(c.data); name != c.name || confidence < 0.5 {
+			t.Errorf("%q: detected %s (%.2f), want %s", c.data, name, confidence, c.name)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package convert
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+)
+
+// InvalidSequenceError reports the first byte sequence that could not be
+// transcoded. Offset counts bytes of the input: charset bytes for a
+// DecodingReader, UTF-8 bytes for an EncodingWriter.
+type InvalidSequenceError struct {
+	Charset string
+	Offset  int64
+}
+
+func (e *InvalidSequenceError) Error() string {
+	return fmt.Sprintf("convert: invalid %s sequence at byte %d", e.Charset, e.Offset)
+}
+
+// StreamOption configures a DecodingReader or EncodingWriter.
+type StreamOption func(*streamOptions)
+
+type streamOptions struct {
+	reject      bool
+	replacement byte
+}
+
+// WithReject fails with an *InvalidSequenceError on the first invalid
+// sequence instead of replacing it.
+func WithReject() StreamOption {
+	return func(o *streamOptions) {
+		o.reject = true
+	}
+}
+
+// WithReplacement sets the byte an EncodingWriter writes for characters
+// the charset cannot represent, '?' by default. Decoding always replaces
+// with U+FFFD.
+func WithReplacement(b byte) StreamOption {
+	return func(o *streamOptions) {
+		o.replacement = b
+	}
+}
+
+func newStreamOptions(opts []StreamOption) *streamOptions {
+	o := &streamOptions{replacement: '?'}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+var _utf8Replacement = []byte(string(utf8.RuneError))
+
+// decoder tracks the input offset around an x/text decoder, which
+// silently replaces invalid sequences with U+FFFD.
+type decoder struct {
+	cs      charset
+	dec     transform.Transformer
+	fffd    []byte // U+FFFD in the charset, nil if not representable
+	reject  bool
+	offset  int64
+	invalid int64
+}
+
+func (t *decoder) Reset() {
+	t.dec.Reset()
+	t.offset = 0
+	t.invalid = -1
+}
+
+func (t *decoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	nDst, nSrc, err = t.dec.Transform(dst, src, atEOF)
+	if t.invalid < 0 || t.reject {
+		if bytes.Contains(dst[:nDst], _utf8Replacement) {
+			if s, d, ok := t.locate(src[:nSrc], atEOF && nSrc == len(src), nDst); ok {
+				if t.invalid < 0 {
+					t.invalid = t.offset + int64(s)
+				}
+				if t.reject {
+					t.offset += int64(s)
+					return d, s, &InvalidSequenceError{Charset: t.cs.name, Offset: t.invalid}
+				}
+			}
+		}
+	}
+	t.offset += int64(nSrc)
+	return nDst, nSrc, err
+}
+
+// locate finds the first U+FFFD the decoder produced for invalid input
+// rather than for an encoded U+FFFD by decoding src again one character
+// at a time. Stateful charsets (ISO-2022-JP, HZ) may not be located.
+func (t *decoder) locate(src []byte, atEOF bool, outLen int) (nSrc, nDst int, ok bool) {
+	dec := t.cs.enc.NewDecoder()
+	var buf [utf8.UTFMax]byte
+	for nSrc < len(src) && nDst < outLen {
+		w := len(_utf8Replacement)
+		nd, ns, err := dec.Transform(buf[:w], src[nSrc:], atEOF)
+		if nd == 0 && ns == 0 && errors.Is(err, transform.ErrShortDst) {
+			nd, ns, err = dec.Transform(buf[:], src[nSrc:], atEOF)
+		}
+		if nd == 0 && ns == 0 {
+			return 0, 0, false
+		}
+		if bytes.Equal(buf[:nd], _utf8Replacement) && !bytes.Equal(src[nSrc:nSrc+ns], t.fffd) {
+			return nSrc, nDst, true
+		}
+		nSrc += ns
+		nDst += nd
+		if err != nil && !errors.Is(err, transform.ErrShortDst) {
+			return 0, 0, false
+		}
+	}
+	return 0, 0, false
+}
+
+// DecodingReader converts a charset stream to UTF-8 as it is read.
+type DecodingReader struct {
+	r io.Reader
+	t *decoder
+}
+
+// NewDecodingReader returns a reader decoding r from charset to UTF-8.
+// Invalid sequences become U+FFFD unless WithReject is given.
+func NewDecodingReader(r io.Reader, charset string, opts ...StreamOption) (*DecodingReader, error) {
+	cs, err := lookup(charset)
+	if err != nil {
+		return nil, err
+	}
+	o := newStreamOptions(opts)
+	t := &decoder{cs: cs, dec: cs.enc.NewDecoder(), reject: o.reject, invalid: -1}
+	if fffd, err := cs.enc.NewEncoder().Bytes(_utf8Replacement); err == nil {
+		t.fffd = fffd
+	}
+	return &DecodingReader{r: transform.NewReader(r, t), t: t}, nil
+}
+
+func (d *DecodingReader) Read(p []byte) (int, error) {
+	return d.r.Read(p)
+}
+
+// InvalidOffset returns the input offset of the first invalid sequence,
+// or -1 if none was seen so far.
+func (d *DecodingReader) InvalidOffset() int64 {
+	return d.t.invalid
+}
+
+// encoder replaces characters the charset cannot represent, recording
+// the offset of the first one.
+type encoder struct {
+	cs          charset
+	enc         transform.Transformer
+	reject      bool
+	replacement byte
+	offset      int64
+	invalid     int64
+}
+
+func (t *encoder) Reset() {
+	t.enc.Reset()
+	t.offset = 0
+	t.invalid = -1
+}
+
+func (t *encoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	defer func() {
+		t.offset += int64(nSrc)
+	}()
+	for {
+		nd, ns, err := t.enc.Transform(dst[nDst:], src[nSrc:], atEOF)
+		nDst += nd
+		nSrc += ns
+		if err == nil || errors.Is(err, transform.ErrShortDst) || errors.Is(err, transform.ErrShortSrc) {
+			return nDst, nSrc, err
+		}
+		// unsupported rune or invalid UTF-8 at src[nSrc]
+		off := t.offset + int64(nSrc)
+		if t.invalid < 0 {
+			t.invalid = off
+		}
+		if t.reject {
+			return nDst, nSrc, &InvalidSequenceError{Charset: t.cs.name, Offset: off}
+		}
+		if nDst >= len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		_, size := utf8.DecodeRune(src[nSrc:])
+		dst[nDst] = t.replacement
+		nDst++
+		nSrc += size
+	}
+}
+
+// EncodingWriter converts UTF-8 to a charset as it is written. Close
+// flushes the buffered tail but does not close the underlying writer.
+type EncodingWriter struct {
+	w *transform.Writer
+	t *encoder
+}
+
+// NewEncodingWriter returns a writer encoding UTF-8 to charset into w.
+// Unrepresentable characters and invalid UTF-8 are replaced by '?' unless
+// WithReject or WithReplacement is given.
+func NewEncodingWriter(w io.Writer, charset string, opts ...StreamOption) (*EncodingWriter, error) {
+	cs, err := lookup(charset)
+	if err != nil {
+		return nil, err
+	}
+	o := newStreamOptions(opts)
+	t := &encoder{
+		cs:          cs,
+		enc:         cs.enc.NewEncoder(),
+		reject:      o.reject,
+		replacement: o.replacement,
+		invalid:     -1,
+	}
+	return &EncodingWriter{w: transform.NewWriter(w, t), t: t}, nil
+}
+
+func (e *EncodingWriter) Write(p []byte) (int, error) {
+	return e.w.Write(p)
+}
+
+// Close flushes pending output.
+func (e *EncodingWriter) Close() error {
+	return e.w.Close()
+}
+
+// InvalidOffset returns the input offset of the first character that
+// could not be encoded, or -1 if none was seen so far.
+func (e *EncodingWriter) InvalidOffset() int64 {
+	return e.t.invalid
+}
@@ -0,0 +1,117 @@
+package convert_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/kakami/pkg/convert"
+)
+
+func Test_DecodingReader(t *testing.T) {
+	text := strings.Repeat("我们在这个国家里生活了很多年。", 500)
+	data, _ := convert.Encode([]byte(text), convert.GBK)
+	r, err := convert.NewDecodingReader(iotest.OneByteReader(bytes.NewReader(data)), "gbk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != text || r.InvalidOffset() != -1 {
+		t.Fatalf("got %d bytes, invalid at %d", len(out), r.InvalidOffset())
+	}
+
+	if _, err := convert.NewDecodingReader(nil, "klingon"); !errors.Is(err, convert.ErrUnknownCharset) {
+		t.Fatalf("got %v, want ErrUnknownCharset", err)
+	}
+}
+
+func Test_DecodingReaderInvalid(t *testing.T) {
+	good, _ := convert.Encode([]byte("中文abc"), convert.GBK)
+	data := append(append([]byte{}, good...), 0x81, 0x20) // lead byte without trail
+	data = append(data, good...)
+	offset := int64(len(good))
+
+	r, _ := convert.NewDecodingReader(bytes.NewReader(data), convert.GBK)
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "中文abc� 中文abc" {
+		t.Fatalf("got %q", out)
+	}
+	if r.InvalidOffset() != offset {
+		t.Fatalf("invalid at %d, want %d", r.InvalidOffset(), offset)
+	}
+
+	r, _ = convert.NewDecodingReader(bytes.NewReader(data), convert.GBK, convert.WithReject())
+	out, err = io.ReadAll(r)
+	var ise *convert.InvalidSequenceError
+	if !errors.As(err, &ise) || ise.Offset != offset || ise.Charset != convert.GBK {
+		t.Fatalf("got %v", err)
+	}
+	if string(out) != "中文abc" {
+		t.Fatalf("got %q before the error", out)
+	}
+
+	// U+FFFD encoded in UTF-8 is not an invalid sequence
+	data = []byte("a�b\xffc")
+	r, _ = convert.NewDecodingReader(bytes.NewReader(data), convert.UTF8)
+	out, _ = io.ReadAll(r)
+	if string(out) != "a�b�c" || r.InvalidOffset() != 5 {
+		t.Fatalf("got %q, invalid at %d", out, r.InvalidOffset())
+	}
+}
+
+func Test_EncodingWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := convert.NewEncodingWriter(&buf, convert.ShiftJIS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := "日本語のテキスト"
+	for _, c := range []byte(text) { // split inside characters
+		if _, err := w.Write([]byte{c}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := convert.Encode([]byte(text), convert.ShiftJIS)
+	if !bytes.Equal(buf.Bytes(), want) || w.InvalidOffset() != -1 {
+		t.Fatalf("got %x, want %x", buf.Bytes(), want)
+	}
+
+	buf.Reset()
+	w, _ = convert.NewEncodingWriter(&buf, convert.Latin1)
+	_, _ = io.WriteString(w, "café 中 ok")
+	_ = w.Close()
+	if buf.String() != "caf\xe9 ? ok" || w.InvalidOffset() != 6 {
+		t.Fatalf("got %q, invalid at %d", buf.String(), w.InvalidOffset())
+	}
+
+	buf.Reset()
+	w, _ = convert.NewEncodingWriter(&buf, convert.Latin1, convert.WithReject())
+	_, err = io.WriteString(w, "café 中 ok")
+	if err == nil {
+		err = w.Close()
+	}
+	var ise *convert.InvalidSequenceError
+	if !errors.As(err, &ise) || ise.Offset != 6 {
+		t.Fatalf("got %v", err)
+	}
+
+	buf.Reset()
+	w, _ = convert.NewEncodingWriter(&buf, convert.GBK, convert.WithReplacement('_'))
+	_, _ = io.WriteString(w, "x한y")
+	_ = w.Close()
+	if buf.String() != "x_y" {
+		t.Fatalf("got %q", buf.String())
+	}
+}
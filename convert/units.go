@@ -0,0 +1,242 @@
+package convert
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Byte size units
+const (
+	KB int64 = 1000
+	MB       = KB * 1000
+	GB       = MB * 1000
+	TB       = GB * 1000
+	PB       = TB * 1000
+	EB       = PB * 1000
+
+	KiB int64 = 1 << 10
+	MiB       = KiB << 10
+	GiB       = MiB << 10
+	TiB       = GiB << 10
+	PiB       = TiB << 10
+	EiB       = PiB << 10
+)
+
+// unit prefixes in ascending order
+const _prefixes = "KMGTPE"
+
+// ParseBytes parses a byte size such as "512", "64MiB", "1.5 GB" or "10k".
+// SI units (kB, MB, ...) are powers of 1000 and IEC units (KiB, MiB, ...)
+// powers of 1024; a bare prefix like "k" or "M" is SI. Units are case
+// insensitive.
+func ParseBytes(s string) (int64, error) {
+	str := strings.TrimSpace(s)
+	i := strings.IndexFunc(str, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-' && r != '+'
+	})
+	num, unit := str, ""
+	if i >= 0 {
+		num, unit = str[:i], strings.TrimSpace(str[i:])
+	}
+	mult, ok := byteUnit(unit)
+	if !ok || num == "" {
+		return 0, bytesError(s, strconv.ErrSyntax)
+	}
+	if n, err := strconv.ParseInt(num, 10, 64); err == nil {
+		if n > math.MaxInt64/mult || n < math.MinInt64/mult {
+			return 0, bytesError(s, strconv.ErrRange)
+		}
+		return n * mult, nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, bytesError(s, strconv.ErrSyntax)
+	}
+	f *= float64(mult)
+	if f >= math.MaxInt64 || f < math.MinInt64 {
+		return 0, bytesError(s, strconv.ErrRange)
+	}
+	return int64(math.Round(f)), nil
+}
+
+// bytesError wraps strconv.ErrSyntax or strconv.ErrRange.
+func bytesError(s string, err error) error {
+	return fmt.Errorf("convert: parse byte size %q: %w", s, err)
+}
+
+func byteUnit(unit string) (int64, bool) {
+	u := strings.ToUpper(unit)
+	switch u {
+	case "", "B":
+		return 1, true
+	}
+	u = strings.TrimSuffix(u, "B")
+	i := strings.IndexByte(_prefixes, u[0])
+	if i < 0 {
+		return 0, false
+	}
+	switch u[1:] {
+	case "":
+		return pow(1000, i+1), true
+	case "I":
+		return 1 << (10 * (i + 1)), true
+	}
+	return 0, false
+}
+
+func pow(base int64, n int) int64 {
+	v := int64(1)
+	for ; n > 0; n-- {
+		v *= base
+	}
+	return v
+}
+
+// FormatBytes formats n with IEC units, e.g. "1.5MiB".
+func FormatBytes(n int64) string {
+	return formatBytes(n, 1024, "iB")
+}
+
+// FormatBytesSI formats n with SI units, e.g. "1.5MB".
+func FormatBytesSI(n int64) string {
+	return formatBytes(n, 1000, "B")
+}
+
+func formatBytes(n int64, base float64, suffix string) string {
+	f := float64(n)
+	if math.Abs(f) < base {
+		return strconv.FormatInt(n, 10) + "B"
+	}
+	i := -1
+	for math.Abs(f) >= base && i < len(_prefixes)-1 {
+		f /= base
+		i++
+	}
+	f = roundTo(f, 2)
+	// rounding may reach the next unit, e.g. 999.999kB
+	if math.Abs(f) >= base && i < len(_prefixes)-1 {
+		f /= base
+		i++
+	}
+	prefix := string(_prefixes[i])
+	if suffix == "B" && prefix == "K" {
+		prefix = "k"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64) + prefix + suffix
+}
+
+func roundTo(f float64, digits int) float64 {
+	p := math.Pow(10, float64(digits))
+	return math.Round(f*p) / p
+}
+
+// FormatRate formats n bytes transferred in d as an IEC rate, e.g. "3.2MiB/s".
+func FormatRate(n int64, d time.Duration) string {
+	return FormatBytes(bytesPerSecond(n, d)) + "/s"
+}
+
+// FormatRateSI formats n bytes transferred in d as an SI rate, e.g. "3.2MB/s".
+func FormatRateSI(n int64, d time.Duration) string {
+	return FormatBytesSI(bytesPerSecond(n, d)) + "/s"
+}
+
+func bytesPerSecond(n int64, d time.Duration) int64 {
+	if d <= 0 {
+		d = time.Millisecond
+	}
+	return int64(float64(n) / d.Seconds())
+}
+
+// Day is 24 hours, ignoring daylight saving changes.
+const Day = 24 * time.Hour
+
+// ParseDuration parses a duration like time.ParseDuration and also
+// accepts days and weeks, e.g. "1d2h" or "1.5w".
+func ParseDuration(s string) (time.Duration, error) {
+	str := strings.TrimSpace(s)
+	if !strings.ContainsAny(str, "dw") {
+		return time.ParseDuration(str)
+	}
+	neg := false
+	if str != "" && (str[0] == '-' || str[0] == '+') {
+		neg = str[0] == '-'
+		str = str[1:]
+	}
+	var d time.Duration
+	var rest strings.Builder
+	for str != "" {
+		i := strings.IndexFunc(str, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.'
+		})
+		j := strings.IndexFunc(str[max(i, 0):], func(r rune) bool {
+			return r >= '0' && r <= '9' || r == '.'
+		})
+		if i <= 0 {
+			return 0, fmt.Errorf("convert: invalid duration %q", s)
+		}
+		if j < 0 {
+			j = len(str)
+		} else {
+			j += i
+		}
+		var unit time.Duration
+		switch str[i:j] {
+		case "d":
+			unit = Day
+		case "w":
+			unit = 7 * Day
+		default:
+			rest.WriteString(str[:j])
+			str = str[j:]
+			continue
+		}
+		f, err := strconv.ParseFloat(str[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("convert: invalid duration %q", s)
+		}
+		d += time.Duration(f * float64(unit))
+		str = str[j:]
+	}
+	if rest.Len() > 0 {
+		r, err := time.ParseDuration(rest.String())
+		if err != nil {
+			return 0, fmt.Errorf("convert: invalid duration %q", s)
+		}
+		d += r
+	}
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+// FormatDuration formats d with days and without zero units, e.g. "1d2h"
+// or "3m0.5s". Durations under a second use time.Duration.String.
+func FormatDuration(d time.Duration) string {
+	if d > -time.Second && d < time.Second {
+		return d.String()
+	}
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	for _, u := range []struct {
+		unit time.Duration
+		name string
+	}{{Day, "d"}, {time.Hour, "h"}, {time.Minute, "m"}} {
+		if d >= u.unit {
+			b.WriteString(strconv.FormatInt(int64(d/u.unit), 10))
+			b.WriteString(u.name)
+			d %= u.unit
+		}
+	}
+	if d > 0 {
+		b.WriteString(strconv.FormatFloat(d.Seconds(), 'f', -1, 64))
+		b.WriteString("s")
+	}
+	return b.String()
+}
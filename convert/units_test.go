@@ -0,0 +1,134 @@
+package convert_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kakami/pkg/convert"
+)
+
+func Test_ParseBytes(t *testing.T) {
+	cases := map[string]int64{
+		"0":        0,
+		"512":      512,
+		"512B":     512,
+		"10k":      10000,
+		"10kB":     10000,
+		"64MiB":    64 << 20,
+		"64 mib":   64 << 20,
+		"1.5GB":    1500000000,
+		"1.5GiB":   3 << 29,
+		"2Ti":      2 << 40,
+		"-1KiB":    -1024,
+		" 7 EiB ":  7 << 60,
+		"0.5KiB":   512,
+		"1PB":      convert.PB,
+		"3 M":      3 * convert.MB,
+		"8.25 kib": 8448,
+	}
+	for s, want := range cases {
+		got, err := convert.ParseBytes(s)
+		if err != nil || got != want {
+			t.Errorf("%q: got %d %v, want %d", s, got, err, want)
+		}
+	}
+
+	for _, s := range []string{"", "MiB", "12XB", "1.2.3KB", "8EiB", "9223372036854775808"} {
+		_, err := convert.ParseBytes(s)
+		if !errors.Is(err, strconv.ErrSyntax) && !errors.Is(err, strconv.ErrRange) {
+			t.Errorf("%q: got %v, want ErrSyntax or ErrRange", s, err)
+		}
+	}
+	if _, err := convert.ParseBytes("8EiB"); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("got %v, want ErrRange", err)
+	}
+}
+
+func Test_FormatBytes(t *testing.T) {
+	cases := []struct {
+		n       int64
+		iec, si string
+	}{
+		{0, "0B", "0B"},
+		{999, "999B", "999B"},
+		{1000, "1000B", "1kB"},
+		{1024, "1KiB", "1.02kB"},
+		{1536, "1.5KiB", "1.54kB"},
+		{64 << 20, "64MiB", "67.11MB"},
+		{-3 << 30, "-3GiB", "-3.22GB"},
+		{1 << 62, "4EiB", "4.61EB"},
+		{999999, "976.56KiB", "1MB"},
+		{1<<20 - 1, "1MiB", "1.05MB"},
+	}
+	for _, c := range cases {
+		if got := convert.FormatBytes(c.n); got != c.iec {
+			t.Errorf("FormatBytes(%d) = %q, want %q", c.n, got, c.iec)
+		}
+		if got := convert.FormatBytesSI(c.n); got != c.si {
+			t.Errorf("FormatBytesSI(%d) = %q, want %q", c.n, got, c.si)
+		}
+		if back, err := convert.ParseBytes(convert.FormatBytes(c.n)); err != nil || (c.n%(1<<10) == 0 && back != c.n) {
+			t.Errorf("round trip %d: %d %v", c.n, back, err)
+		}
+	}
+
+	if got := convert.FormatRate(3<<20, 2*time.Second); got != "1.5MiB/s" {
+		t.Errorf("rate %q", got)
+	}
+	if got := convert.FormatRate(100, 0); got != "97.66KiB/s" {
+		t.Errorf("rate %q", got)
+	}
+	if got := convert.FormatRateSI(3e6, 2*time.Second); got != "1.5MB/s" {
+		t.Errorf("rate %q", got)
+	}
+	if got := convert.FormatRateSI(100, 0); got != "100kB/s" {
+		t.Errorf("rate %q", got)
+	}
+}
+
+func Test_Duration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"1d2h":      26 * time.Hour,
+		"2h1d":      26 * time.Hour,
+		"1.5d":      36 * time.Hour,
+		"1w":        7 * convert.Day,
+		"-1d30m":    -(convert.Day + 30*time.Minute),
+		"90s":       90 * time.Second,
+		"1d1h1m1s":  convert.Day + time.Hour + time.Minute + time.Second,
+		"300ms":     300 * time.Millisecond,
+		"2d500ms":   2*convert.Day + 500*time.Millisecond,
+		"1d0h0m0s":  convert.Day,
+		"0.25w1d1h": 2*convert.Day + 19*time.Hour,
+	}
+	for s, want := range cases {
+		got, err := convert.ParseDuration(s)
+		if err != nil || got != want {
+			t.Errorf("%q: got %v %v, want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"d", "1x", "1dd", "1d2", "abc"} {
+		if _, err := convert.ParseDuration(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+
+	formats := map[time.Duration]string{
+		0:                                "0s",
+		150 * time.Millisecond:           "150ms",
+		26 * time.Hour:                   "1d2h",
+		convert.Day + 90*time.Second:     "1d1m30s",
+		-(3*time.Minute + time.Second/2): "-3m0.5s",
+		48 * time.Hour:                   "2d",
+	}
+	for d, want := range formats {
+		got := convert.FormatDuration(d)
+		if got != want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", int64(d), got, want)
+		}
+		if back, err := convert.ParseDuration(got); err != nil || back != d {
+			t.Errorf("round trip %q: %v %v", got, back, err)
+		}
+	}
+}
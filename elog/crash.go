@@ -0,0 +1,263 @@
+package elog
+
+import (
+    "bufio"
+    "bytes"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "runtime"
+    "runtime/debug"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/kakami/pkg/zlog"
+)
+
+const (
+    _crashPrefix     = "crash-"
+    _crashSuffix     = ".log"
+    _crashTimeLayout = "20060102-150405.000000"
+    _maxStackSize    = 64 << 20
+)
+
+// CrashOption configures a CrashReporter.
+type CrashOption func(*crashOptions)
+
+type crashOptions struct {
+    keep int
+    tail *zlog.TailWriter
+}
+
+// WithKeep sets how many crash files are kept, oldest are removed first.
+// Default is 10, 0 keeps all.
+func WithKeep(k int) CrashOption {
+    return func(o *crashOptions) {
+        o.keep = k
+    }
+}
+
+// WithLogTail attaches the last lines remembered by tail to every crash
+// file. Install tail as the zlog writer with zlog.SetWriter.
+func WithLogTail(tail *zlog.TailWriter) CrashOption {
+    return func(o *crashOptions) {
+        o.tail = tail
+    }
+}
+
+// CrashReporter writes one file per crash into a directory, holding the
+// time, build info, command line, all goroutines and recent log lines.
+//
+// Panics are caught with Recover or Go. Crashes it cannot catch, like
+// unrecovered panics in other goroutines or fatal runtime errors, end up on
+// stderr: RedirectStderr sends stderr to a file and turns the trace a
+// previous run left there into a crash file.
+type CrashReporter struct {
+    dir  string
+    opts crashOptions
+    mu   sync.Mutex
+}
+
+// NewCrashReporter returns a reporter writing into dir, creating it if
+// needed.
+func NewCrashReporter(dir string, opts ...CrashOption) (*CrashReporter, error) {
+    o := crashOptions{keep: 10}
+    for _, opt := range opts {
+        opt(&o)
+    }
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return nil, err
+    }
+    return &CrashReporter{dir: dir, opts: o}, nil
+}
+
+// Recover reports a panic and panics again, so the process still dies
+// with the usual trace. It must be deferred directly:
+//
+//  defer reporter.Recover()
+func (c *CrashReporter) Recover() {
+    if r := recover(); r != nil {
+        c.Report(fmt.Sprintf("panic: %v", r))
+        panic(r)
+    }
+}
+
+// Go runs fn in a new goroutine that reports panics.
+func (c *CrashReporter) Go(fn func()) {
+    go func() {
+        defer c.Recover()
+        fn()
+    }()
+}
+
+// Report writes a crash file for reason with a dump of all goroutines and
+// returns its path.
+func (c *CrashReporter) Report(reason string) (string, error) {
+    return c.write(time.Now(), reason, goroutines(), "goroutines")
+}
+
+// RedirectStderr collects a crash trace left in path by a previous run,
+// see Collect, then redirects stderr to path like RedirectStderr.
+func (c *CrashReporter) RedirectStderr(path string, opts ...RedirectOption) (*Redirect, error) {
+    if _, err := c.Collect(path); err != nil && !os.IsNotExist(err) {
+        return nil, err
+    }
+    return RedirectStderr(path, opts...)
+}
+
+// Collect looks for a Go panic or fatal error trace in the stderr file at
+// path. If found, the trace is moved from path into a new crash file whose
+// path is returned, otherwise the returned path is empty. The build info
+// recorded is that of the running binary. The file is streamed, only the
+// trace is read into memory, up to 64MiB of it.
+func (c *CrashReporter) Collect(path string) (string, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return "", err
+    }
+    defer f.Close()
+    i, err := traceStart(f)
+    if err != nil || i < 0 {
+        return "", err
+    }
+    st, err := f.Stat()
+    if err != nil {
+        return "", err
+    }
+    if _, err := f.Seek(i, io.SeekStart); err != nil {
+        return "", err
+    }
+    trace, err := io.ReadAll(io.LimitReader(f, _maxStackSize))
+    if err != nil {
+        return "", err
+    }
+    reason := trace
+    if j := bytes.IndexByte(reason, '\n'); j >= 0 {
+        reason = reason[:j]
+    }
+    name, err := c.write(st.ModTime(), "previous run: "+string(reason), trace, "stderr")
+    if err != nil {
+        return "", err
+    }
+    return name, os.Truncate(path, i)
+}
+
+// traceStart returns the offset of the first line in r starting a Go
+// crash trace, or -1.
+func traceStart(r io.Reader) (int64, error) {
+    br := bufio.NewReader(r)
+    var off int64
+    lineStart := true
+    for {
+        line, err := br.ReadSlice('\n')
+        if lineStart && (bytes.HasPrefix(line, []byte("panic: ")) || bytes.HasPrefix(line, []byte("fatal error: "))) {
+            return off, nil
+        }
+        off += int64(len(line))
+        // a line longer than the buffer comes in several slices
+        lineStart = err == nil
+        switch err {
+        case nil, bufio.ErrBufferFull:
+        case io.EOF:
+            return -1, nil
+        default:
+            return -1, err
+        }
+    }
+}
+
+func (c *CrashReporter) write(t time.Time, reason string, dump []byte, dumpName string) (string, error) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    name := filepath.Join(c.dir, fmt.Sprintf("%s%s-%d%s", _crashPrefix, t.Format(_crashTimeLayout), os.Getpid(), _crashSuffix))
+    f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+    if err != nil {
+        return "", err
+    }
+    w := bufio.NewWriter(f)
+    fmt.Fprintf(w, "time:    %s\n", t.Format(time.RFC3339Nano))
+    fmt.Fprintf(w, "reason:  %s\n", reason)
+    fmt.Fprintf(w, "pid:     %d\n", os.Getpid())
+    fmt.Fprintf(w, "cmdline: %s\n", strings.Join(os.Args, " "))
+    writeBuildInfo(w)
+
+    fmt.Fprintf(w, "\n--- %s ---\n", dumpName)
+    w.Write(dump)
+    if len(dump) > 0 && dump[len(dump)-1] != '\n' {
+        w.WriteByte('\n')
+    }
+    if c.opts.tail != nil {
+        lines := c.opts.tail.Lines()
+        fmt.Fprintf(w, "\n--- last %d log lines ---\n", len(lines))
+        for _, line := range lines {
+            w.WriteString(line)
+            w.WriteByte('\n')
+        }
+    }
+
+    err = w.Flush()
+    if cerr := f.Close(); err == nil {
+        err = cerr
+    }
+    if err != nil {
+        return "", err
+    }
+    return name, c.rotate()
+}
+
+func writeBuildInfo(w *bufio.Writer) {
+    fmt.Fprintf(w, "go:      %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+    bi, ok := debug.ReadBuildInfo()
+    if !ok {
+        return
+    }
+    fmt.Fprintf(w, "module:  %s %s\n", bi.Main.Path, bi.Main.Version)
+    for _, s := range bi.Settings {
+        if strings.HasPrefix(s.Key, "vcs.") {
+            fmt.Fprintf(w, "%s: %s\n", s.Key, s.Value)
+        }
+    }
+}
+
+// rotate removes the oldest crash files beyond keep.
+func (c *CrashReporter) rotate() error {
+    if c.opts.keep <= 0 {
+        return nil
+    }
+    files, err := c.Files()
+    if err != nil {
+        return err
+    }
+    for len(files) > c.opts.keep {
+        if err := os.Remove(files[0]); err != nil {
+            return err
+        }
+        files = files[1:]
+    }
+    return nil
+}
+
+// Files returns the crash files in the directory, oldest first.
+func (c *CrashReporter) Files() ([]string, error) {
+    files, err := filepath.Glob(filepath.Join(c.dir, _crashPrefix+"*"+_crashSuffix))
+    if err != nil {
+        return nil, err
+    }
+    sort.Strings(files)
+    return files, nil
+}
+
+func goroutines() []byte {
+    buf := make([]byte, 64<<10)
+    for {
+        n := runtime.Stack(buf, true)
+        if n < len(buf) || len(buf) >= _maxStackSize {
+            return buf[:n]
+        }
+        buf = make([]byte, 2*len(buf))
+    }
+}
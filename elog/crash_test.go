@@ -0,0 +1,104 @@
+package elog_test
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/kakami/pkg/elog"
+    "github.com/kakami/pkg/zlog"
+)
+
+func Test_CrashReporter(t *testing.T) {
+    dir := t.TempDir()
+    tail := zlog.NewTailWriter(nil, 2)
+    c, err := elog.NewCrashReporter(dir, elog.WithKeep(2), elog.WithLogTail(tail))
+    if err != nil {
+        t.Fatal(err)
+    }
+    tail.Write([]byte("one\ntwo\nthree\n"))
+
+    func() {
+        defer func() {
+            if r := recover(); r != "boom" {
+                t.Fatalf("recovered %v", r)
+            }
+        }()
+        defer c.Recover()
+        panic("boom")
+    }()
+
+    files, err := c.Files()
+    if err != nil || len(files) != 1 {
+        t.Fatal(files, err)
+    }
+    data, err := os.ReadFile(files[0])
+    if err != nil {
+        t.Fatal(err)
+    }
+    s := string(data)
+    for _, want := range []string{
+        "reason:  panic: boom\n",
+        "cmdline: " + os.Args[0],
+        "go:      go",
+        "--- goroutines ---\ngoroutine ",
+        "Test_CrashReporter",
+        "--- last 2 log lines ---\ntwo\nthree\n",
+    } {
+        if !strings.Contains(s, want) {
+            t.Errorf("crash file misses %q:\n%s", want, s)
+        }
+    }
+
+    for i := 0; i < 3; i++ {
+        if _, err := c.Report("manual"); err != nil {
+            t.Fatal(err)
+        }
+    }
+    files, _ = c.Files()
+    if len(files) != 2 {
+        t.Fatalf("kept %d files", len(files))
+    }
+}
+
+func Test_CrashReporter_Collect(t *testing.T) {
+    dir := t.TempDir()
+    c, err := elog.NewCrashReporter(filepath.Join(dir, "crash"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    stderr := filepath.Join(dir, "stderr.log")
+
+    os.WriteFile(stderr, []byte("some output\n"), 0644)
+    if name, err := c.Collect(stderr); err != nil || name != "" {
+        t.Fatal(name, err)
+    }
+
+    trace := "fatal error: concurrent map writes\n\ngoroutine 1 [running]:\nmain.main()\n"
+    os.WriteFile(stderr, []byte("some output\n"+trace), 0644)
+    name, err := c.Collect(stderr)
+    if err != nil || name == "" {
+        t.Fatal(name, err)
+    }
+    data, _ := os.ReadFile(name)
+    if !strings.Contains(string(data), "reason:  previous run: fatal error: concurrent map writes\n") ||
+        !strings.Contains(string(data), "--- stderr ---\n"+trace) {
+        t.Fatalf("crash file:\n%s", data)
+    }
+    data, _ = os.ReadFile(stderr)
+    if string(data) != "some output\n" {
+        t.Fatalf("stderr left %q", data)
+    }
+
+    // a header in the middle of a long line is not a trace
+    long := strings.Repeat("x", 10000) + "panic: not at the start\n"
+    os.WriteFile(stderr, []byte(long+"panic: boom\n"), 0644)
+    if name, err = c.Collect(stderr); err != nil || name == "" {
+        t.Fatal(name, err)
+    }
+    data, _ = os.ReadFile(stderr)
+    if string(data) != long {
+        t.Fatalf("stderr left %d bytes", len(data))
+    }
+}
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package elog
+
+import (
+    "errors"
+    "os"
+)
+
+var errUnsupported = errors.New("elog: stderr redirection is not supported on this platform")
+
+func saveStderr() (*os.File, error) {
+    return nil, errUnsupported
+}
+
+func setStderr(f *os.File) error {
+    return errUnsupported
+}
@@ -0,0 +1,24 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package elog
+
+import (
+    "os"
+    "syscall"
+)
+
+// saveStderr duplicates the stderr descriptor so it can be restored.
+func saveStderr() (*os.File, error) {
+    fd, err := syscall.Dup(int(os.Stderr.Fd()))
+    if err != nil {
+        return nil, err
+    }
+    syscall.CloseOnExec(fd)
+    return os.NewFile(uintptr(fd), "/dev/stderr"), nil
+}
+
+// setStderr points the stderr descriptor at f.
+func setStderr(f *os.File) error {
+    return dup2(int(f.Fd()), int(os.Stderr.Fd()))
+}
@@ -24,17 +24,15 @@ func setStdHandle(stdhandle int32, handle syscall.Handle) error {
     return nil
 }
 
-// RedirectStderr to the file passed in
-func RedirectStderr() (err error) {
-    logFile, err := os.OpenFile("./test-error.log", os.O_WRONLY|os.O_CREATE|os.O_SYNC|os.O_APPEND, 0644)
-    if err != nil {
-        return
-    }
-    err = setStdHandle(syscall.STD_ERROR_HANDLE, syscall.Handle(logFile.Fd()))
-    if err != nil {
-        return
+func saveStderr() (*os.File, error) {
+    return os.Stderr, nil
+}
+
+func setStderr(f *os.File) error {
+    if err := setStdHandle(syscall.STD_ERROR_HANDLE, syscall.Handle(f.Fd())); err != nil {
+        return err
     }
     // SetStdHandle does not affect prior references to stderr
-    os.Stderr = logFile
-    return
+    os.Stderr = f
+    return nil
 }
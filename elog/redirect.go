@@ -0,0 +1,218 @@
+package elog
+
+import (
+    "fmt"
+    "io"
+    "os"
+    "os/signal"
+    "sync"
+    "syscall"
+    "time"
+)
+
+// RedirectOption configures RedirectStderr.
+type RedirectOption func(*redirectOptions)
+
+type redirectOptions struct {
+    hup         bool
+    tee         bool
+    teeInterval time.Duration
+}
+
+// WithReopenOnHUP reopens the file on SIGHUP, so logrotate can move it
+// away and signal the process.
+func WithReopenOnHUP() RedirectOption {
+    return func(o *redirectOptions) {
+        o.hup = true
+    }
+}
+
+// WithTee also copies everything written to the file to the original
+// stderr. The descriptor keeps pointing at the file, so nothing is lost
+// there when the process dies; the copy follows the file every interval
+// (100ms by default) and may miss the last output before a crash.
+func WithTee(interval time.Duration) RedirectOption {
+    return func(o *redirectOptions) {
+        o.tee = true
+        if interval > 0 {
+            o.teeInterval = interval
+        }
+    }
+}
+
+// Redirect is an active stderr redirection.
+type Redirect struct {
+    path string
+    opts redirectOptions
+
+    mu     sync.Mutex
+    file   *os.File
+    orig   *os.File // original stderr
+    tail   *os.File // follows file for tee
+    offset int64
+    closed bool
+
+    sigc chan os.Signal
+    done chan struct{}
+    wg   sync.WaitGroup
+}
+
+// RedirectStderr points the process stderr, and so Go panic traces, at
+// the file path until Restore is called.
+func RedirectStderr(path string, opts ...RedirectOption) (*Redirect, error) {
+    r := &Redirect{
+        path: path,
+        opts: redirectOptions{teeInterval: 100 * time.Millisecond},
+        done: make(chan struct{}),
+    }
+    for _, opt := range opts {
+        opt(&r.opts)
+    }
+
+    orig, err := saveStderr()
+    if err != nil {
+        return nil, err
+    }
+    r.orig = orig
+    if err := r.open(); err != nil {
+        if orig != os.Stderr {
+            orig.Close()
+        }
+        return nil, err
+    }
+
+    if r.opts.hup {
+        r.sigc = make(chan os.Signal, 1)
+        signal.Notify(r.sigc, syscall.SIGHUP)
+    }
+    if r.opts.hup || r.opts.tee {
+        r.wg.Add(1)
+        go r.loop()
+    }
+    return r, nil
+}
+
+// Path returns the file stderr is redirected to.
+func (r *Redirect) Path() string {
+    return r.path
+}
+
+// open opens path and points stderr at it, closing the previous file.
+// r.mu must be held once the redirect is running.
+func (r *Redirect) open() error {
+    f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_SYNC|os.O_APPEND, 0644)
+    if err != nil {
+        return err
+    }
+    var tail *os.File
+    var offset int64
+    if r.opts.tee {
+        if tail, err = os.Open(r.path); err == nil {
+            offset, err = tail.Seek(0, io.SeekEnd)
+        }
+        if err != nil {
+            f.Close()
+            if tail != nil {
+                tail.Close()
+            }
+            return err
+        }
+    }
+    if err := setStderr(f); err != nil {
+        f.Close()
+        if tail != nil {
+            tail.Close()
+        }
+        return err
+    }
+
+    if r.file != nil {
+        r.file.Close()
+    }
+    if r.tail != nil {
+        r.copyTail()
+        r.tail.Close()
+    }
+    r.file, r.tail, r.offset = f, tail, offset
+    return nil
+}
+
+// Reopen opens the file again, e.g. after it was rotated.
+func (r *Redirect) Reopen() error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if r.closed {
+        return os.ErrClosed
+    }
+    return r.open()
+}
+
+// Restore points stderr back at the original descriptor and closes the
+// file. It is safe to call more than once.
+func (r *Redirect) Restore() error {
+    r.mu.Lock()
+    if r.closed {
+        r.mu.Unlock()
+        return nil
+    }
+    r.closed = true
+    r.mu.Unlock()
+
+    if r.sigc != nil {
+        signal.Stop(r.sigc)
+    }
+    close(r.done)
+    r.wg.Wait()
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if r.tail != nil {
+        r.copyTail()
+        r.tail.Close()
+        r.tail = nil
+    }
+    err := setStderr(r.orig)
+    if r.orig != os.Stderr {
+        r.orig.Close()
+    }
+    r.file.Close()
+    return err
+}
+
+func (r *Redirect) loop() {
+    defer r.wg.Done()
+
+    var tick <-chan time.Time
+    if r.opts.tee {
+        t := time.NewTicker(r.opts.teeInterval)
+        defer t.Stop()
+        tick = t.C
+    }
+    for {
+        select {
+        case <-r.done:
+            return
+        case <-r.sigc:
+            if err := r.Reopen(); err != nil && err != os.ErrClosed {
+                fmt.Fprintf(os.Stderr, "elog: reopen %s: %s\n", r.path, err.Error())
+            }
+        case <-tick:
+            r.mu.Lock()
+            if r.tail != nil {
+                r.copyTail()
+            }
+            r.mu.Unlock()
+        }
+    }
+}
+
+// copyTail copies what was appended to the file since the last call to
+// the original stderr. r.mu must be held.
+func (r *Redirect) copyTail() {
+    if st, err := r.tail.Stat(); err == nil && st.Size() < r.offset {
+        // truncated in place, e.g. logrotate copytruncate
+        r.offset, _ = r.tail.Seek(0, io.SeekStart)
+    }
+    n, _ := io.Copy(r.orig, r.tail)
+    r.offset += n
+}
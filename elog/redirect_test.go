@@ -0,0 +1,92 @@
+package elog_test
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "runtime"
+    "strings"
+    "syscall"
+    "testing"
+    "time"
+
+    "github.com/kakami/pkg/elog"
+)
+
+func readFile(t *testing.T, path string) string {
+    t.Helper()
+    data, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return string(data)
+}
+
+func Test_RedirectStderr(t *testing.T) {
+    dir := t.TempDir()
+    outer := filepath.Join(dir, "outer.log")
+    inner := filepath.Join(dir, "inner.log")
+
+    ro, err := elog.RedirectStderr(outer)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer ro.Restore()
+
+    ri, err := elog.RedirectStderr(inner, elog.WithReopenOnHUP(), elog.WithTee(10*time.Millisecond))
+    if err != nil {
+        t.Fatal(err)
+    }
+    fmt.Fprintln(os.Stderr, "one")
+
+    if err := os.Rename(inner, inner+".1"); err != nil {
+        t.Fatal(err)
+    }
+    if err := ri.Reopen(); err != nil {
+        t.Fatal(err)
+    }
+    fmt.Fprintln(os.Stderr, "two")
+
+    if runtime.GOOS != "windows" {
+        os.Rename(inner, inner+".2")
+        p, _ := os.FindProcess(os.Getpid())
+        p.Signal(syscall.SIGHUP)
+        deadline := time.Now().Add(time.Second)
+        for {
+            if _, err := os.Stat(inner); err == nil || time.Now().After(deadline) {
+                break
+            }
+            time.Sleep(5 * time.Millisecond)
+        }
+        fmt.Fprintln(os.Stderr, "three")
+    }
+
+    if err := ri.Restore(); err != nil {
+        t.Fatal(err)
+    }
+    if err := ri.Restore(); err != nil {
+        t.Fatal(err)
+    }
+    fmt.Fprintln(os.Stderr, "four")
+    if err := ro.Restore(); err != nil {
+        t.Fatal(err)
+    }
+
+    if got := readFile(t, inner+".1"); got != "one\n" {
+        t.Errorf("rotated file has %q", got)
+    }
+    if runtime.GOOS != "windows" {
+        if got := readFile(t, inner+".2"); got != "two\n" {
+            t.Errorf("second rotated file has %q", got)
+        }
+        if got := readFile(t, inner); got != "three\n" {
+            t.Errorf("current file has %q", got)
+        }
+    }
+    got := readFile(t, outer)
+    for _, want := range []string{"one\n", "two\n", "four\n"} {
+        if !strings.Contains(got, want) {
+            t.Errorf("tee misses %q: %q", want, got)
+        }
+    }
+}
@@ -3,17 +3,29 @@ package rmet
 import (
     "net"
     "net/netip"
+    "sync"
+    "time"
 )
 
+// MUDPConn meters a UDP conn. Besides bytes it counts packets, their
+// sizes and how long Read and Write calls take, see Flow.
 type MUDPConn struct {
     *net.UDPConn
     *RMet
+    reg     *Registry
+    payload func([]byte) int
 }
 
-func NewUDPConn(conn *net.UDPConn, n int) *MUDPConn {
+// NewUDPConn wraps conn, WithSizeBuckets, WithLatencyBuckets and
+// WithPayloadSize apply.
+func NewUDPConn(conn *net.UDPConn, n int, opts ...Option) *MUDPConn {
+    o := newOptions(opts)
+    rm := New(n)
+    rm.pkts = newPackets(o.sizeBuckets, o.latencyBuckets)
     return &MUDPConn{
         UDPConn: conn,
-        RMet:    New(n),
+        RMet:    rm,
+        payload: o.payloadSize,
     }
 }
 
@@ -21,78 +33,125 @@ func (c *MUDPConn) RMetrics() *RMet {
     return c.RMet
 }
 
+// Close closes the conn and removes it from the Registry that created it.
+func (c *MUDPConn) Close() error {
+    if c.reg != nil {
+        c.reg.Unregister(c.RMet)
+    }
+    return c.UDPConn.Close()
+}
+
+// recv counts a read of n bytes into b.
+func (c *MUDPConn) recv(b []byte, n int, err error) {
+    c.AddBytesRecv(int64(n))
+    c.readErr(err)
+    if err != nil {
+        return
+    }
+    c.packetRecv(n)
+    if c.payload != nil {
+        c.AddDataRecv(int64(c.payload(b[:n])))
+    }
+}
+
+// sent counts a write of n bytes from b started at start.
+func (c *MUDPConn) sent(b []byte, n int, start time.Time, err error) {
+    c.AddBytesSent(int64(n))
+    c.writeErr(err)
+    if err != nil {
+        return
+    }
+    c.packetSent(n, time.Since(start))
+    if c.payload != nil {
+        c.AddDataSent(int64(c.payload(b[:n])))
+    }
+}
+
 func (c *MUDPConn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
     n, addr, err := c.UDPConn.ReadFromUDP(b)
-    c.AddBytesRecv(int64(n))
+    c.recv(b, n, err)
     return n, addr, err
 }
 
 func (c *MUDPConn) ReadFrom(b []byte) (int, net.Addr, error) {
     n, addr, err := c.UDPConn.ReadFrom(b)
-    c.AddBytesRecv(int64(n))
+    c.recv(b, n, err)
     return n, addr, err
 }
 
 func (c *MUDPConn) ReadFromUDPAddrPort(b []byte) (int, netip.AddrPort, error) {
     n, addr, err := c.UDPConn.ReadFromUDPAddrPort(b)
-    c.AddBytesRecv(int64(n))
+    c.recv(b, n, err)
     return n, addr, err
 }
 
 func (c *MUDPConn) ReadMsgUDP(b, oob []byte) (n, oobn, flags int, addr *net.UDPAddr, err error) {
     n, oobn, flags, addr, err = c.UDPConn.ReadMsgUDP(b, oob)
-    c.AddBytesRecv(int64(n))
+    c.recv(b, n, err)
     return
 }
 
 func (c *MUDPConn) ReadMsgUDPAddrPort(b, oob []byte) (n, oobn, flags int, addr netip.AddrPort, err error) {
     n, oobn, flags, addr, err = c.UDPConn.ReadMsgUDPAddrPort(b, oob)
-    c.AddBytesRecv(int64(n))
+    c.recv(b, n, err)
     return
 }
 
 func (c *MUDPConn) WriteToUDP(b []byte, addr *net.UDPAddr) (int, error) {
-    c.AddBytesSent(int64(len(b)))
-    return c.UDPConn.WriteToUDP(b, addr)
+    start := time.Now()
+    n, err := c.UDPConn.WriteToUDP(b, addr)
+    c.sent(b, n, start, err)
+    return n, err
 }
 
 func (c *MUDPConn) WriteToUDPAddrPort(b []byte, addr netip.AddrPort) (int, error) {
-    c.AddBytesSent(int64(len(b)))
-    return c.UDPConn.WriteToUDPAddrPort(b, addr)
+    start := time.Now()
+    n, err := c.UDPConn.WriteToUDPAddrPort(b, addr)
+    c.sent(b, n, start, err)
+    return n, err
 }
 
 func (c *MUDPConn) WriteTo(b []byte, addr net.Addr) (int, error) {
-    c.AddBytesSent(int64(len(b)))
-    return c.UDPConn.WriteTo(b, addr)
+    start := time.Now()
+    n, err := c.UDPConn.WriteTo(b, addr)
+    c.sent(b, n, start, err)
+    return n, err
 }
 
 func (c *MUDPConn) WriteMsgUDP(b, oob []byte, addr *net.UDPAddr) (n, oobn int, err error) {
+    start := time.Now()
     n, oobn, err = c.UDPConn.WriteMsgUDP(b, oob, addr)
-    c.AddBytesSent(int64(n))
+    c.sent(b, n, start, err)
     return
 }
 
 func (c *MUDPConn) WriteMsgUDPAddrPort(b, oob []byte, addr netip.AddrPort) (n, oobn int, err error) {
+    start := time.Now()
     n, oobn, err = c.UDPConn.WriteMsgUDPAddrPort(b, oob, addr)
-    c.AddBytesSent(int64(n))
+    c.sent(b, n, start, err)
     return
 }
 
 func (c *MUDPConn) Write(b []byte) (int, error) {
+    start := time.Now()
     n, err := c.UDPConn.Write(b)
-    c.AddBytesSent(int64(n))
+    c.sent(b, n, start, err)
     return n, err
 }
 
 func (c *MUDPConn) Read(b []byte) (int, error) {
     n, err := c.UDPConn.Read(b)
-    c.AddBytesRecv(int64(n))
+    c.recv(b, n, err)
     return n, err
 }
 
 type MConn struct {
     net.Conn
     *RMet
+    reg *Registry
+
+    closeOnce sync.Once
+    onClose   func() // set by Listener and Dialer
 }
 
 func NewConn(conn net.Conn, n int) *MConn {
@@ -106,21 +165,37 @@ func (c *MConn) RMetrics() *RMet {
     return c.RMet
 }
 
+// Close closes the conn and removes it from the Registry that created it.
+func (c *MConn) Close() error {
+    c.closeOnce.Do(func() {
+        if c.reg != nil {
+            c.reg.Unregister(c.RMet)
+        }
+        if c.onClose != nil {
+            c.onClose()
+        }
+    })
+    return c.Conn.Close()
+}
+
 func (c *MConn) Read(b []byte) (int, error) {
     n, err := c.Conn.Read(b)
     c.AddBytesRecv(int64(n))
+    c.readErr(err)
     return n, err
 }
 
 func (c *MConn) Write(b []byte) (int, error) {
     n, err := c.Conn.Write(b)
     c.AddBytesSent(int64(n))
+    c.writeErr(err)
     return n, err
 }
 
 type MPacketConn struct {
     net.PacketConn
     *RMet
+    reg *Registry
 }
 
 func NewPacketConn(conn net.PacketConn, n int) *MPacketConn {
@@ -134,14 +209,24 @@ func (c *MPacketConn) RMetrics() *RMet {
     return c.RMet
 }
 
+// Close closes the conn and removes it from the Registry that created it.
+func (c *MPacketConn) Close() error {
+    if c.reg != nil {
+        c.reg.Unregister(c.RMet)
+    }
+    return c.PacketConn.Close()
+}
+
 func (c *MPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
     n, addr, err := c.PacketConn.ReadFrom(b)
     c.AddBytesRecv(int64(n))
+    c.readErr(err)
     return n, addr, err
 }
 
 func (c *MPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
     n, err := c.PacketConn.WriteTo(b, addr)
     c.AddBytesSent(int64(n))
+    c.writeErr(err)
     return n, err
 }
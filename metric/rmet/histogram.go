@@ -0,0 +1,97 @@
+package rmet
+
+import (
+    "math"
+    "sort"
+
+    "go.uber.org/atomic"
+)
+
+var (
+    // DefaultSizeBuckets are packet size bounds in bytes.
+    DefaultSizeBuckets = []float64{64, 128, 256, 512, 1024, 1200, 1500, 4096, 9000, 65535}
+    // DefaultLatencyBuckets are Write call duration bounds in seconds.
+    DefaultLatencyBuckets = []float64{
+        5e-6, 10e-6, 25e-6, 50e-6, 100e-6, 250e-6, 500e-6,
+        1e-3, 2.5e-3, 5e-3, 10e-3, 25e-3, 100e-3, 1,
+    }
+)
+
+// Histogram counts observations into buckets by upper bound.
+type Histogram struct {
+    bounds []float64
+    counts []atomic.Int64 // per bucket, the last is above all bounds
+    count  atomic.Int64
+    sum    atomic.Float64
+}
+
+// NewHistogram returns a histogram with the given upper bounds.
+func NewHistogram(bounds []float64) *Histogram {
+    b := append([]float64(nil), bounds...)
+    sort.Float64s(b)
+    return &Histogram{
+        bounds: b,
+        counts: make([]atomic.Int64, len(b)+1),
+    }
+}
+
+func (h *Histogram) Observe(v float64) {
+    h.counts[sort.SearchFloat64s(h.bounds, v)].Inc()
+    h.count.Inc()
+    h.sum.Add(v)
+}
+
+func (h *Histogram) Snapshot() HistogramSnapshot {
+    s := HistogramSnapshot{
+        Bounds: h.bounds,
+        Counts: make([]int64, len(h.counts)),
+        Sum:    h.sum.Load(),
+    }
+    for i := range h.counts {
+        s.Counts[i] = h.counts[i].Load()
+        s.Count += s.Counts[i]
+    }
+    return s
+}
+
+// HistogramSnapshot is the state of a Histogram.
+type HistogramSnapshot struct {
+    Bounds []float64 `json:"bounds"`
+    // per bucket, not cumulative; the last counts values above all bounds
+    Counts []int64  `json:"counts"`
+    Count  int64    `json:"count"`
+    Sum    float64  `json:"sum"`
+}
+
+// Quantile estimates the q-quantile, 0 to 1, interpolating linearly
+// within the bucket. Values above the highest bound are reported as it.
+func (s HistogramSnapshot) Quantile(q float64) float64 {
+    if s.Count == 0 || len(s.Bounds) == 0 {
+        return 0
+    }
+    rank := math.Max(0, math.Min(1, q)) * float64(s.Count)
+    var seen int64
+    for i, n := range s.Counts {
+        if n == 0 || float64(seen+n) < rank {
+            seen += n
+            continue
+        }
+        if i == len(s.Bounds) {
+            break
+        }
+        lower := 0.0
+        if i > 0 {
+            lower = s.Bounds[i-1]
+        }
+        return lower + (s.Bounds[i]-lower)*(rank-float64(seen))/float64(n)
+    }
+    return s.Bounds[len(s.Bounds)-1]
+}
+
+// Mean returns the average observation.
+func (s HistogramSnapshot) Mean() float64 {
+    if s.Count == 0 {
+        return 0
+    }
+    return s.Sum / float64(s.Count)
+}
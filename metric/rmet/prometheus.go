@@ -0,0 +1,344 @@
+package rmet
+
+import (
+    "bufio"
+    "io"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+const _contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type family struct {
+    name  string
+    typ   string
+    help  string
+    value func(s *series) float64
+}
+
+var _families = []family{
+    {"rmet_sent_bytes_total", "counter", "Bytes sent on the wire.",
+        total(func(c *counts) int64 { return c.bytesSent })},
+    {"rmet_sent_data_bytes_total", "counter", "Payload bytes sent.",
+        total(func(c *counts) int64 { return c.dataSent })},
+    {"rmet_received_bytes_total", "counter", "Bytes received on the wire.",
+        total(func(c *counts) int64 { return c.bytesRecv })},
+    {"rmet_received_data_bytes_total", "counter", "Payload bytes received.",
+        total(func(c *counts) int64 { return c.dataRecv })},
+    {"rmet_write_errors_total", "counter", "Failed writes.",
+        total(func(c *counts) int64 { return c.writeErrs })},
+    {"rmet_read_errors_total", "counter", "Failed reads, not counting EOF.",
+        total(func(c *counts) int64 { return c.readErrs })},
+    {"rmet_sent_data_bytes_per_second", "gauge", "Payload bytes sent per second over the last tick.",
+        func(s *series) float64 { return s.sumLast(func(l Snapshot) float64 { return l.Sent.Rate }) }},
+    {"rmet_received_data_bytes_per_second", "gauge", "Payload bytes received per second over the last tick.",
+        func(s *series) float64 { return s.sumLast(func(l Snapshot) float64 { return l.Recv.Rate }) }},
+    {"rmet_sent_wire_ratio", "gauge", "Wire bytes per payload byte sent over the last tick.",
+        func(s *series) float64 { return s.ratio(func(l Snapshot) Flow { return l.Sent }) }},
+    {"rmet_received_wire_ratio", "gauge", "Wire bytes per payload byte received over the last tick.",
+        func(s *series) float64 { return s.ratio(func(l Snapshot) Flow { return l.Recv }) }},
+    {"rmet_cpu_percent", "gauge", "Process CPU usage at the last tick.",
+        func(s *series) float64 {
+            cpu := 0.0
+            for _, l := range s.lasts {
+                cpu = max(cpu, l.CPU)
+            }
+            return cpu
+        }},
+}
+
+// series sums the instances registered under the same name and labels,
+// Prometheus does not accept the same series twice.
+type series struct {
+    labels string
+    counts counts     // including the instances unregistered meanwhile
+    lasts  []Snapshot // of the instances ticked at least once
+}
+
+// counts holds the counters of an RMet, or the sum of several.
+type counts struct {
+    bytesSent, dataSent int64
+    bytesRecv, dataRecv int64
+    writeErrs, readErrs int64
+
+    // set if any instance counts packets
+    pkts               bool
+    sentPkts, recvPkts int64
+    hists              []HistogramSnapshot // in _histFamilies order
+}
+
+func (r *RMet) counts() counts {
+    c := counts{
+        bytesSent: r.bytesSent.Load(),
+        dataSent:  r.dataSent.Load(),
+        bytesRecv: r.bytesRecv.Load(),
+        dataRecv:  r.dataRecv.Load(),
+        writeErrs: r.writeErrs.Load(),
+        readErrs:  r.readErrs.Load(),
+    }
+    if p := r.pkts; p != nil {
+        c.pkts = true
+        c.sentPkts = p.sent.Load()
+        c.recvPkts = p.recv.Load()
+        c.hists = make([]HistogramSnapshot, len(_histFamilies))
+        for i, f := range _histFamilies {
+            c.hists[i] = f.hist(p).Snapshot()
+        }
+    }
+    return c
+}
+
+func (c *counts) add(o counts) {
+    c.bytesSent += o.bytesSent
+    c.dataSent += o.dataSent
+    c.bytesRecv += o.bytesRecv
+    c.dataRecv += o.dataRecv
+    c.writeErrs += o.writeErrs
+    c.readErrs += o.readErrs
+    if !o.pkts {
+        return
+    }
+    c.sentPkts += o.sentPkts
+    c.recvPkts += o.recvPkts
+    if !c.pkts {
+        c.pkts = true
+        c.hists = make([]HistogramSnapshot, len(o.hists))
+        copy(c.hists, o.hists)
+        return
+    }
+    for i := range c.hists {
+        c.hists[i] = mergeHistograms([]HistogramSnapshot{c.hists[i], o.hists[i]})
+    }
+}
+
+func total(value func(c *counts) int64) func(s *series) float64 {
+    return func(s *series) float64 {
+        return float64(value(&s.counts))
+    }
+}
+
+func (s *series) sumLast(value func(l Snapshot) float64) float64 {
+    v := 0.0
+    for _, l := range s.lasts {
+        v += value(l)
+    }
+    return v
+}
+
+// ratio is Flow.Ratio over the summed deltas, 0 if nothing ticked.
+func (s *series) ratio(flow func(l Snapshot) Flow) float64 {
+    if len(s.lasts) == 0 {
+        return 0
+    }
+    var bytes, data int64
+    for _, l := range s.lasts {
+        f := flow(l)
+        bytes += f.DeltaBytes
+        data += f.DeltaData
+    }
+    return float64(bytes+1) / float64(data+1)
+}
+
+// collect groups the registered instances by their formatted labels.
+// The counters are read under the registry lock, so an instance
+// unregistered meanwhile is either summed or carried over, not both.
+func (g *Registry) collect() []*series {
+    g.mu.RLock()
+    defer g.mu.RUnlock()
+    var out []*series
+    byLabels := make(map[string]*series)
+    for _, e := range g.sorted() {
+        s, ok := byLabels[e.key]
+        if !ok {
+            s = &series{labels: e.key}
+            if c, ok := g.carried[e.key]; ok {
+                s.counts.add(*c)
+            }
+            byLabels[e.key] = s
+            out = append(out, s)
+        }
+        s.counts.add(e.rm.counts())
+        if last, ok := e.rm.Last(); ok {
+            s.lasts = append(s.lasts, last)
+        }
+    }
+    return out
+}
+
+// Handler renders all registered instances in the Prometheus text
+// exposition format. Every sample carries a name label plus the labels
+// given to Register.
+func (g *Registry) Handler() http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+        w.Header().Set("Content-Type", _contentType)
+        g.WriteText(w)
+    })
+}
+
+// Handler serves DefaultRegistry, see Registry.Handler.
+func Handler() http.Handler {
+    return DefaultRegistry.Handler()
+}
+
+// WriteText writes all registered instances in the Prometheus text
+// exposition format. Instances sharing a name and labels are summed into
+// one series, which keeps the counters of its unregistered instances for
+// as long as any instance is left, so the series never decreases.
+func (g *Registry) WriteText(w io.Writer) error {
+    all := g.collect()
+
+    bw := bufio.NewWriter(w)
+    for _, f := range _families {
+        if len(all) == 0 {
+            break
+        }
+        bw.WriteString("# HELP " + f.name + " " + f.help + "\n")
+        bw.WriteString("# TYPE " + f.name + " " + f.typ + "\n")
+        for _, s := range all {
+            bw.WriteString(f.name)
+            bw.WriteString(s.labels)
+            bw.WriteByte(' ')
+            bw.WriteString(strconv.FormatFloat(f.value(s), 'g', -1, 64))
+            bw.WriteByte('\n')
+        }
+    }
+    writePackets(bw, all)
+    return bw.Flush()
+}
+
+type histFamily struct {
+    name string
+    help string
+    hist func(p *packets) *Histogram
+}
+
+var _histFamilies = []histFamily{
+    {"rmet_sent_packet_size_bytes", "Sizes of packets sent.", func(p *packets) *Histogram { return p.sentSize }},
+    {"rmet_received_packet_size_bytes", "Sizes of packets received.", func(p *packets) *Histogram { return p.recvSize }},
+    {"rmet_write_duration_seconds", "Duration of packet Write calls.", func(p *packets) *Histogram { return p.writeLatency }},
+}
+
+// writePackets writes the packet counters and histograms of the series
+// counting packets.
+func writePackets(bw *bufio.Writer, all []*series) {
+    var counting []*series
+    for _, s := range all {
+        if s.counts.pkts {
+            counting = append(counting, s)
+        }
+    }
+    if len(counting) == 0 {
+        return
+    }
+
+    for _, f := range []struct {
+        name, help string
+        value      func(c *counts) int64
+    }{
+        {"rmet_sent_packets_total", "Packets sent.", func(c *counts) int64 { return c.sentPkts }},
+        {"rmet_received_packets_total", "Packets received.", func(c *counts) int64 { return c.recvPkts }},
+    } {
+        bw.WriteString("# HELP " + f.name + " " + f.help + "\n")
+        bw.WriteString("# TYPE " + f.name + " counter\n")
+        for _, s := range counting {
+            bw.WriteString(f.name + s.labels + " " + strconv.FormatInt(f.value(&s.counts), 10) + "\n")
+        }
+    }
+
+    for i, f := range _histFamilies {
+        bw.WriteString("# HELP " + f.name + " " + f.help + "\n")
+        bw.WriteString("# TYPE " + f.name + " histogram\n")
+        for _, s := range counting {
+            h := s.counts.hists[i]
+            prefix := s.labels[:len(s.labels)-1] + `,le="`
+            var cum int64
+            for j, bound := range h.Bounds {
+                cum += h.Counts[j]
+                bw.WriteString(f.name + "_bucket" + prefix + strconv.FormatFloat(bound, 'g', -1, 64) + `"} ` + strconv.FormatInt(cum, 10) + "\n")
+            }
+            bw.WriteString(f.name + "_bucket" + prefix + `+Inf"} ` + strconv.FormatInt(h.Count, 10) + "\n")
+            bw.WriteString(f.name + "_sum" + s.labels + " " + strconv.FormatFloat(h.Sum, 'g', -1, 64) + "\n")
+            bw.WriteString(f.name + "_count" + s.labels + " " + strconv.FormatInt(h.Count, 10) + "\n")
+        }
+    }
+}
+
+// mergeHistograms sums snaps into the bounds of the first. A bucket of a
+// snapshot with other bounds is counted in the lowest bound at or above
+// its own.
+func mergeHistograms(snaps []HistogramSnapshot) HistogramSnapshot {
+    out := HistogramSnapshot{
+        Bounds: snaps[0].Bounds,
+        Counts: make([]int64, len(snaps[0].Counts)),
+    }
+    for _, s := range snaps {
+        for i, n := range s.Counts {
+            j := len(out.Bounds)
+            if i < len(s.Bounds) {
+                j = sort.SearchFloat64s(out.Bounds, s.Bounds[i])
+            }
+            out.Counts[j] += n
+        }
+        out.Count += s.Count
+        out.Sum += s.Sum
+    }
+    return out
+}
+
+// formatLabels renders name and labels as a Prometheus label set, fixing
+// invalid label names and escaping values. Of the labels whose fixed names
+// collide, e.g. a-b and a_b, the first in key order is kept. A label le,
+// reserved for histogram buckets, is renamed to _le.
+func formatLabels(name string, labels Labels) string {
+    keys := make([]string, 0, len(labels))
+    for k := range labels {
+        if k != "name" {
+            keys = append(keys, k)
+        }
+    }
+    sort.Strings(keys)
+
+    var b strings.Builder
+    b.WriteString(`{name="`)
+    b.WriteString(escapeLabel(name))
+    b.WriteByte('"')
+    seen := map[string]bool{"name": true}
+    for _, k := range keys {
+        ln := labelName(k)
+        if ln == "le" {
+            ln = "_le"
+        }
+        if seen[ln] {
+            continue
+        }
+        seen[ln] = true
+        b.WriteByte(',')
+        b.WriteString(ln)
+        b.WriteString(`="`)
+        b.WriteString(escapeLabel(labels[k]))
+        b.WriteByte('"')
+    }
+    b.WriteByte('}')
+    return b.String()
+}
+
+func labelName(s string) string {
+    b := []byte(s)
+    for i, c := range b {
+        if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+            b[i] = '_'
+        }
+    }
+    if len(b) == 0 {
+        return "_"
+    }
+    return string(b)
+}
+
+var _labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+    return _labelEscaper.Replace(s)
+}
@@ -0,0 +1,189 @@
+package rmet_test
+
+import (
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    dto "github.com/prometheus/client_model/go"
+    "github.com/prometheus/common/expfmt"
+
+    "github.com/kakami/pkg/metric/rmet"
+)
+
+func labelsOf(m *dto.Metric) map[string]string {
+    out := make(map[string]string)
+    for _, lp := range m.GetLabel() {
+        out[lp.GetName()] = lp.GetValue()
+    }
+    return out
+}
+
+func Test_Handler(t *testing.T) {
+    reg := rmet.NewRegistry()
+    a, b := rmet.New(10), rmet.New(10)
+    reg.Register("relay", a, rmet.Labels{"conn": "1", "peer": `10.0.0.1:"x"`})
+    reg.Register("relay", b, rmet.Labels{"conn": "2", "bad-key": "v"})
+
+    a.AddBytesSent(150)
+    a.AddDataSent(100)
+    a.AddBytesRecv(30)
+    a.AddDataRecv(20)
+    a.Tick()
+    b.AddDataRecv(5)
+
+    srv := httptest.NewServer(reg.Handler())
+    defer srv.Close()
+    resp, err := srv.Client().Get(srv.URL)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer resp.Body.Close()
+    if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+        t.Fatalf("content type %q", ct)
+    }
+
+    var parser expfmt.TextParser
+    families, err := parser.TextToMetricFamilies(resp.Body)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    want := map[string]map[string]float64{
+        "rmet_sent_bytes_total":          {"1": 150, "2": 0},
+        "rmet_sent_data_bytes_total":     {"1": 100, "2": 0},
+        "rmet_received_bytes_total":      {"1": 30, "2": 0},
+        "rmet_received_data_bytes_total": {"1": 20, "2": 5},
+    }
+    for name, byConn := range want {
+        f := families[name]
+        if f == nil || f.GetType() != dto.MetricType_COUNTER {
+            t.Fatalf("%s: missing or not a counter: %v", name, f)
+        }
+        for _, m := range f.GetMetric() {
+            l := labelsOf(m)
+            if l["name"] != "relay" {
+                t.Errorf("%s: labels %v", name, l)
+            }
+            if got := m.GetCounter().GetValue(); got != byConn[l["conn"]] {
+                t.Errorf("%s conn %s: got %v, want %v", name, l["conn"], got, byConn[l["conn"]])
+            }
+        }
+    }
+
+    for _, name := range []string{
+        "rmet_sent_data_bytes_per_second", "rmet_received_data_bytes_per_second",
+        "rmet_sent_wire_ratio", "rmet_received_wire_ratio", "rmet_cpu_percent",
+    } {
+        f := families[name]
+        if f == nil || f.GetType() != dto.MetricType_GAUGE || len(f.GetMetric()) != 2 {
+            t.Fatalf("%s: %v", name, f)
+        }
+    }
+    for _, m := range families["rmet_sent_data_bytes_per_second"].GetMetric() {
+        l := labelsOf(m)
+        switch l["conn"] {
+        case "1":
+            if m.GetGauge().GetValue() <= 0 || l["peer"] != `10.0.0.1:"x"` {
+                t.Errorf("conn 1: %v", m)
+            }
+        case "2":
+            if m.GetGauge().GetValue() != 0 || l["bad_key"] != "v" {
+                t.Errorf("conn 2: %v", m)
+            }
+        }
+    }
+
+    reg.Unregister(a)
+    reg.Unregister(b)
+    rec := httptest.NewRecorder()
+    reg.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+    if rec.Body.Len() != 0 {
+        t.Fatalf("empty registry rendered %q", rec.Body.String())
+    }
+}
+
+func Test_HandlerSameLabels(t *testing.T) {
+    reg := rmet.NewRegistry()
+    a, b, c := rmet.New(10), rmet.New(10), rmet.New(10)
+    reg.Register("server", a, rmet.Labels{"peer": "a"})
+    reg.Register("server", b, rmet.Labels{"peer": "a"})
+    reg.Register("server", c, rmet.Labels{"a-b": "x", "a_b": "y"})
+    a.AddBytesSent(100)
+    b.AddBytesSent(50)
+    c.AddBytesSent(1)
+    a.Tick()
+
+    var buf strings.Builder
+    if err := reg.WriteText(&buf); err != nil {
+        t.Fatal(err)
+    }
+    var parser expfmt.TextParser
+    families, err := parser.TextToMetricFamilies(strings.NewReader(buf.String()))
+    if err != nil {
+        t.Fatalf("%v\n%s", err, buf.String())
+    }
+    f := families["rmet_sent_bytes_total"]
+    if len(f.GetMetric()) != 2 {
+        t.Fatalf("series %v", f.GetMetric())
+    }
+    for _, m := range f.GetMetric() {
+        l := labelsOf(m)
+        switch {
+        case l["peer"] == "a":
+            if v := m.GetCounter().GetValue(); v != 150 {
+                t.Errorf("peer a: %v", v)
+            }
+        case l["a_b"] == "x":
+            if v := m.GetCounter().GetValue(); v != 1 || len(l) != 2 {
+                t.Errorf("colliding labels: %v %v", l, v)
+            }
+        default:
+            t.Errorf("unexpected labels %v", l)
+        }
+    }
+}
+
+func Test_HandlerUnregister(t *testing.T) {
+    reg := rmet.NewRegistry()
+    a, b := rmet.New(10), rmet.New(10)
+    reg.Register("server", a, rmet.Labels{"peer": "a", "le": "x"})
+    reg.Register("server", b, rmet.Labels{"peer": "a", "le": "x"})
+    a.AddBytesSent(100)
+    b.AddBytesSent(50)
+
+    sent := func() (float64, map[string]string) {
+        var buf strings.Builder
+        if err := reg.WriteText(&buf); err != nil {
+            t.Fatal(err)
+        }
+        var parser expfmt.TextParser
+        families, err := parser.TextToMetricFamilies(strings.NewReader(buf.String()))
+        if err != nil {
+            t.Fatalf("%v\n%s", err, buf.String())
+        }
+        ms := families["rmet_sent_bytes_total"].GetMetric()
+        if len(ms) == 0 {
+            return 0, nil
+        }
+        return ms[0].GetCounter().GetValue(), labelsOf(ms[0])
+    }
+
+    if v, l := sent(); v != 150 || l["_le"] != "x" || l["le"] != "" {
+        t.Fatalf("got %v %v", v, l)
+    }
+    // the counters of a stay with the series while b is registered
+    reg.Unregister(a)
+    b.AddBytesSent(10)
+    if v, _ := sent(); v != 160 {
+        t.Fatalf("after unregister: %v", v)
+    }
+    reg.Unregister(b)
+    if v, l := sent(); v != 0 || l != nil {
+        t.Fatalf("empty series: %v %v", v, l)
+    }
+    reg.Register("server", a, rmet.Labels{"peer": "a", "le": "x"})
+    if v, _ := sent(); v != 100 {
+        t.Fatalf("registered again: %v", v)
+    }
+}
@@ -0,0 +1,273 @@
+package rmet
+
+import (
+    "net"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Labels are attached to a registered RMet, e.g. per connection.
+type Labels map[string]string
+
+type entry struct {
+    name   string
+    labels Labels
+    key    string // Prometheus label set, see formatLabels
+    rm     *RMet
+}
+
+// Registry holds named RMet instances. Conns created by its NewConn,
+// NewPacketConn and NewUDPConn are registered until they are closed.
+type Registry struct {
+    mu      sync.RWMutex
+    entries map[*RMet]*entry
+    // per key, the number of entries and the counters of those gone
+    live    map[string]int
+    carried map[string]*counts
+
+    tmu  sync.Mutex
+    stop chan struct{}
+    wg   sync.WaitGroup
+}
+
+// DefaultRegistry is used by the package level Register, Unregister and
+// Handler.
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+    return &Registry{
+        entries: make(map[*RMet]*entry),
+        live:    make(map[string]int),
+        carried: make(map[string]*counts),
+    }
+}
+
+// Register adds r under name and labels, registering it again replaces
+// them.
+func (g *Registry) Register(name string, r *RMet, labels Labels) {
+    cp := make(Labels, len(labels))
+    for k, v := range labels {
+        cp[k] = v
+    }
+    e := &entry{name: name, labels: cp, key: formatLabels(name, cp), rm: r}
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    if old, ok := g.entries[r]; ok {
+        if old.key == e.key {
+            old.name, old.labels = name, cp
+            return
+        }
+        g.remove(old)
+    }
+    g.entries[r] = e
+    g.live[e.key]++
+}
+
+func (g *Registry) Unregister(r *RMet) {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    if e, ok := g.entries[r]; ok {
+        g.remove(e)
+        delete(g.entries, r)
+    }
+}
+
+// remove carries the counters of e over to the other entries of its key.
+// g.mu must be held.
+func (g *Registry) remove(e *entry) {
+    if g.live[e.key]--; g.live[e.key] == 0 {
+        delete(g.live, e.key)
+        delete(g.carried, e.key)
+        return
+    }
+    c, ok := g.carried[e.key]
+    if !ok {
+        c = &counts{}
+        g.carried[e.key] = c
+    }
+    c.add(e.rm.counts())
+}
+
+// Len returns the number of registered instances.
+func (g *Registry) Len() int {
+    g.mu.RLock()
+    defer g.mu.RUnlock()
+    return len(g.entries)
+}
+
+// NewConn wraps conn like NewConn and registers it until Close.
+func (g *Registry) NewConn(conn net.Conn, n int, name string, labels Labels) *MConn {
+    c := NewConn(conn, n)
+    c.reg = g
+    g.Register(name, c.RMet, labels)
+    return c
+}
+
+// NewPacketConn wraps conn like NewPacketConn and registers it until Close.
+func (g *Registry) NewPacketConn(conn net.PacketConn, n int, name string, labels Labels) *MPacketConn {
+    c := NewPacketConn(conn, n)
+    c.reg = g
+    g.Register(name, c.RMet, labels)
+    return c
+}
+
+// NewUDPConn wraps conn like NewUDPConn and registers it until Close.
+func (g *Registry) NewUDPConn(conn *net.UDPConn, n int, name string, labels Labels, opts ...Option) *MUDPConn {
+    c := NewUDPConn(conn, n, opts...)
+    c.reg = g
+    g.Register(name, c.RMet, labels)
+    return c
+}
+
+// Group aggregates the instances sharing a name and the values of the
+// labels grouped by. Only registered instances are counted, the totals of
+// closed conns are gone with them.
+type Group struct {
+    Name   string
+    Labels Labels
+    Count  int
+
+    BytesSent, DataSent int64
+    BytesRecv, DataRecv int64
+    // payload bytes per second over the last tick
+    SendRate, RecvRate float64
+}
+
+// Aggregate sums the registered instances per name and values of the
+// label keys in by, e.g. Aggregate("peer"). Instances without a key group
+// under an empty value. Groups are sorted by name and labels.
+func (g *Registry) Aggregate(by ...string) []Group {
+    groups := make(map[string]*Group)
+    var keys []string
+    for _, e := range g.all() {
+        labels := make(Labels, len(by))
+        for _, k := range by {
+            labels[k] = e.labels[k]
+        }
+        key := e.name + labels.String()
+        gr, ok := groups[key]
+        if !ok {
+            gr = &Group{Name: e.name, Labels: labels}
+            groups[key] = gr
+            keys = append(keys, key)
+        }
+        last, _ := e.rm.Last()
+        gr.Count++
+        gr.BytesSent += e.rm.bytesSent.Load()
+        gr.DataSent += e.rm.dataSent.Load()
+        gr.BytesRecv += e.rm.bytesRecv.Load()
+        gr.DataRecv += e.rm.dataRecv.Load()
+        gr.SendRate += last.Sent.Rate
+        gr.RecvRate += last.Recv.Rate
+    }
+    sort.Strings(keys)
+    out := make([]Group, len(keys))
+    for i, key := range keys {
+        out[i] = *groups[key]
+    }
+    return out
+}
+
+// Start ticks every registered instance each interval in the background,
+// so callers need not call Tick. It does nothing if already started. A
+// non-positive interval means one second.
+func (g *Registry) Start(interval time.Duration) {
+    if interval <= 0 {
+        interval = time.Second
+    }
+    g.tmu.Lock()
+    defer g.tmu.Unlock()
+    if g.stop != nil {
+        return
+    }
+    g.stop = make(chan struct{})
+    g.wg.Add(1)
+    go g.loop(interval, g.stop)
+}
+
+// Stop stops the ticker started by Start.
+func (g *Registry) Stop() {
+    g.tmu.Lock()
+    defer g.tmu.Unlock()
+    if g.stop == nil {
+        return
+    }
+    close(g.stop)
+    g.wg.Wait()
+    g.stop = nil
+}
+
+func (g *Registry) loop(interval time.Duration, stop chan struct{}) {
+    defer g.wg.Done()
+    t := time.NewTicker(interval)
+    defer t.Stop()
+    for {
+        select {
+        case <-stop:
+            return
+        case now := <-t.C:
+            // one CPU sample for all instances
+            p, _ := _proc.CPUPercent()
+            for _, e := range g.all() {
+                e.rm.tick(now, p)
+            }
+        }
+    }
+}
+
+func (g *Registry) all() []*entry {
+    g.mu.RLock()
+    defer g.mu.RUnlock()
+    out := make([]*entry, 0, len(g.entries))
+    for _, e := range g.entries {
+        out = append(out, e)
+    }
+    return out
+}
+
+// sorted returns the entries sorted by name and labels. g.mu must be held.
+func (g *Registry) sorted() []*entry {
+    out := make([]*entry, 0, len(g.entries))
+    for _, e := range g.entries {
+        out = append(out, e)
+    }
+    sort.Slice(out, func(i, j int) bool {
+        if out[i].name != out[j].name {
+            return out[i].name < out[j].name
+        }
+        return out[i].labels.String() < out[j].labels.String()
+    })
+    return out
+}
+
+// String formats the labels sorted by key, e.g. `{a="1",b="2"}`.
+func (l Labels) String() string {
+    keys := make([]string, 0, len(l))
+    for k := range l {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    var b strings.Builder
+    b.WriteByte('{')
+    for i, k := range keys {
+        if i > 0 {
+            b.WriteByte(',')
+        }
+        b.WriteString(k)
+        b.WriteString(`="`)
+        b.WriteString(l[k])
+        b.WriteByte('"')
+    }
+    b.WriteByte('}')
+    return b.String()
+}
+
+func Register(name string, r *RMet, labels Labels) {
+    DefaultRegistry.Register(name, r, labels)
+}
+
+func Unregister(r *RMet) {
+    DefaultRegistry.Unregister(r)
+}
@@ -0,0 +1,75 @@
+package rmet_test
+
+import (
+    "io"
+    "net"
+    "testing"
+    "time"
+
+    "github.com/kakami/pkg/metric/rmet"
+)
+
+func Test_Registry(t *testing.T) {
+    reg := rmet.NewRegistry()
+
+    var conns []*rmet.MConn
+    for _, peer := range []string{"a", "a", "b"} {
+        c1, c2 := net.Pipe()
+        c := reg.NewConn(c1, 10, "server", rmet.Labels{"peer": peer})
+        conns = append(conns, c)
+        go io.Copy(io.Discard, c2)
+        defer c2.Close()
+    }
+    for i, c := range conns {
+        payload := make([]byte, 100*(i+1))
+        if _, err := c.Write(payload); err != nil {
+            t.Fatal(err)
+        }
+        c.AddDataSent(int64(len(payload)))
+    }
+    if reg.Len() != 3 {
+        t.Fatalf("registered %d", reg.Len())
+    }
+
+    reg.Start(10 * time.Millisecond)
+    time.Sleep(50 * time.Millisecond)
+    reg.Stop()
+    reg.Stop()
+
+    groups := reg.Aggregate("peer")
+    if len(groups) != 2 {
+        t.Fatalf("groups %+v", groups)
+    }
+    a, b := groups[0], groups[1]
+    if a.Labels["peer"] != "a" || a.Count != 2 || a.BytesSent != 300 || a.DataSent != 300 {
+        t.Errorf("group a %+v", a)
+    }
+    if b.Labels["peer"] != "b" || b.Count != 1 || b.BytesSent != 300 {
+        t.Errorf("group b %+v", b)
+    }
+    if all := reg.Aggregate(); len(all) != 1 || all[0].Count != 3 || all[0].DataSent != 600 {
+        t.Errorf("total %+v", all)
+    }
+
+    conns[0].Close()
+    if reg.Len() != 2 {
+        t.Fatalf("registered %d after close", reg.Len())
+    }
+    if groups := reg.Aggregate("peer"); groups[0].Count != 1 || groups[0].BytesSent != 200 {
+        t.Errorf("group a after close %+v", groups[0])
+    }
+    for _, c := range conns[1:] {
+        c.Close()
+    }
+    if reg.Len() != 0 {
+        t.Fatalf("registered %d after closing all", reg.Len())
+    }
+}
+
+func Test_RegistryStartNonPositive(t *testing.T) {
+    reg := rmet.NewRegistry()
+    reg.Start(0)
+    reg.Stop()
+    reg.Start(-time.Second)
+    reg.Stop()
+}
@@ -2,32 +2,33 @@ package rmet
 
 import (
     "container/ring"
-    "fmt"
+    "io"
     "os"
     "sync"
     "time"
 
     "github.com/shirou/gopsutil/v3/process"
     "go.uber.org/atomic"
+
+    "github.com/kakami/pkg/convert"
 )
 
 type RMet struct {
-    bytesSent  *atomic.Int64
-    dataSent   *atomic.Int64
-    bytesRecv  *atomic.Int64
-    dataRecv   *atomic.Int64
-    timestamp  time.Time
-    sts        time.Time
-    srates     *ring.Ring
-    sbandwidth *ring.Ring
-    rrates     *ring.Ring
-    rbandwidth *ring.Ring
-    cpus       *ring.Ring
-
-    totalBytesSent, totalBytesRecv int64
-    TotalDataSent, totalDataRecv   int64
-
-    tt time.Time
+    bytesSent *atomic.Int64
+    dataSent  *atomic.Int64
+    bytesRecv *atomic.Int64
+    dataRecv  *atomic.Int64
+    readErrs  *atomic.Int64
+    writeErrs *atomic.Int64
+    timestamp time.Time
+    sts       time.Time
+    history   *ring.Ring // of Snapshot, at the newest
+    pkts      *packets   // nil unless packets are counted
+
+    totalBytesSent, totalBytesRecv     int64
+    TotalDataSent, totalDataRecv       int64
+    totalPacketsSent, totalPacketsRecv int64
+
     mu sync.Mutex
 }
 
@@ -39,17 +40,15 @@ func New(size int) *RMet {
     n := min(size, 1000)
     n = max(n, 10)
     rm := &RMet{
-        bytesSent:  atomic.NewInt64(0),
-        dataSent:   atomic.NewInt64(0),
-        bytesRecv:  atomic.NewInt64(0),
-        dataRecv:   atomic.NewInt64(0),
-        srates:     ring.New(n),
-        sbandwidth: ring.New(n),
-        rrates:     ring.New(n),
-        rbandwidth: ring.New(n),
-        cpus:       ring.New(n),
-        timestamp:  time.Now(),
-        sts:        time.Now(),
+        bytesSent: atomic.NewInt64(0),
+        dataSent:  atomic.NewInt64(0),
+        bytesRecv: atomic.NewInt64(0),
+        dataRecv:  atomic.NewInt64(0),
+        readErrs:  atomic.NewInt64(0),
+        writeErrs: atomic.NewInt64(0),
+        history:   ring.New(n),
+        timestamp: time.Now(),
+        sts:       time.Now(),
     }
     return rm
 }
@@ -70,89 +69,150 @@ func (r *RMet) AddDataRecv(n int64) {
     r.dataRecv.Add(n)
 }
 
+// AddReadError counts a failed read.
+func (r *RMet) AddReadError() {
+    r.readErrs.Inc()
+}
+
+// AddWriteError counts a failed write.
+func (r *RMet) AddWriteError() {
+    r.writeErrs.Inc()
+}
+
+// readErr counts err unless it is nil or io.EOF.
+func (r *RMet) readErr(err error) {
+    if err != nil && err != io.EOF {
+        r.readErrs.Inc()
+    }
+}
+
+func (r *RMet) writeErr(err error) {
+    if err != nil {
+        r.writeErrs.Inc()
+    }
+}
+
+// packets holds the per packet metrics of an MUDPConn.
+type packets struct {
+    sent, recv         *atomic.Int64
+    sentSize, recvSize *Histogram // wire bytes
+    writeLatency       *Histogram // seconds
+}
+
+func newPackets(sizeBuckets, latencyBuckets []float64) *packets {
+    return &packets{
+        sent:         atomic.NewInt64(0),
+        recv:         atomic.NewInt64(0),
+        sentSize:     NewHistogram(sizeBuckets),
+        recvSize:     NewHistogram(sizeBuckets),
+        writeLatency: NewHistogram(latencyBuckets),
+    }
+}
+
+// packetSent counts a written packet of n wire bytes that took d.
+func (r *RMet) packetSent(n int, d time.Duration) {
+    r.pkts.sent.Inc()
+    r.pkts.sentSize.Observe(float64(n))
+    r.pkts.writeLatency.Observe(d.Seconds())
+}
+
+// packetRecv counts a read packet of n wire bytes.
+func (r *RMet) packetRecv(n int) {
+    r.pkts.recv.Inc()
+    r.pkts.recvSize.Observe(float64(n))
+}
+
+// Metrics returns the history of send rates, send wire ratios, receive
+// rates, receive wire ratios and CPU. Rates are in bytes per millisecond.
+//
+// Deprecated: use History or Summary.
 func (r *RMet) Metrics() ([]float64, []float64, []float64, []float64, []float64) {
     r.mu.Lock()
     defer r.mu.Unlock()
     var srates, sbandwidth, rrates, rbandwidth, cpus []float64
-    r.srates.Do(func(x any) {
-        if x != nil {
-            srates = append(srates, x.(float64))
-        }
-    })
-    r.sbandwidth.Do(func(x any) {
-        if x != nil {
-            sbandwidth = append(sbandwidth, x.(float64))
-        }
-    })
-    r.rrates.Do(func(x any) {
-        if x != nil {
-            rrates = append(rrates, x.(float64))
-        }
-    })
-    r.rbandwidth.Do(func(x any) {
-        if x != nil {
-            rbandwidth = append(rbandwidth, x.(float64))
-        }
-    })
-    r.cpus.Do(func(x any) {
-        if x != nil {
-            cpus = append(cpus, x.(float64))
+    r.history.Do(func(x any) {
+        if s, ok := x.(Snapshot); ok {
+            srates = append(srates, s.Sent.Rate/1000)
+            sbandwidth = append(sbandwidth, s.Sent.Ratio)
+            rrates = append(rrates, s.Recv.Rate/1000)
+            rbandwidth = append(rbandwidth, s.Recv.Ratio)
+            cpus = append(cpus, s.CPU)
         }
     })
     return srates, sbandwidth, rrates, rbandwidth, cpus
 }
 
+// Tick advances the history and formats the new Snapshot.
 func (r *RMet) Tick() string {
+    return r.TickSnapshot().String()
+}
+
+// TickSnapshot advances the history and returns the new Snapshot.
+func (r *RMet) TickSnapshot() Snapshot {
+    p, _ := _proc.CPUPercent()
+    return r.tick(time.Now(), p)
+}
+
+// tick advances the history to now, cpu is the process CPU usage.
+func (r *RMet) tick(now time.Time, cpu float64) Snapshot {
     r.mu.Lock()
     defer r.mu.Unlock()
-    r.tt = r.timestamp
-    r.timestamp = time.Now()
-    totalBytesSent := r.bytesSent.Load()
-    totalDataSent := r.dataSent.Load()
-    totalBytesRecv := r.bytesRecv.Load()
-    totalDataRecv := r.dataRecv.Load()
-    ttd := int64(r.timestamp.Sub(r.tt) / time.Millisecond)
-    if ttd < 1 {
-        ttd = 1
+    prev := r.timestamp
+    r.timestamp = now
+    interval := max(now.Sub(prev), time.Millisecond)
+    elapsed := max(now.Sub(r.sts), time.Millisecond)
+    bytesSent, dataSent := r.bytesSent.Load(), r.dataSent.Load()
+    bytesRecv, dataRecv := r.bytesRecv.Load(), r.dataRecv.Load()
+    s := Snapshot{
+        Time:     now,
+        Start:    r.sts,
+        Interval: now.Sub(prev),
+        Sent:     newFlow(bytesSent, dataSent, r.totalBytesSent, r.TotalDataSent, interval, elapsed),
+        Recv:     newFlow(bytesRecv, dataRecv, r.totalBytesRecv, r.totalDataRecv, interval, elapsed),
+        CPU:      cpu,
     }
-    tst := int64(r.timestamp.Sub(r.sts) / time.Millisecond)
-    if tst < 1 {
-        tst = 1
+    s.Sent.Errors = r.writeErrs.Load()
+    s.Recv.Errors = r.readErrs.Load()
+    if r.pkts != nil {
+        sent, recv := r.pkts.sent.Load(), r.pkts.recv.Load()
+        s.Sent.setPackets(sent, r.totalPacketsSent, interval, r.pkts.sentSize, r.pkts.writeLatency)
+        s.Recv.setPackets(recv, r.totalPacketsRecv, interval, r.pkts.recvSize, nil)
+        r.totalPacketsSent, r.totalPacketsRecv = sent, recv
     }
-    p, _ := _proc.CPUPercent()
-    out := fmt.Sprintf(`sent rate(c/a): %s, %s, bandwidth(c/a): %.3f, %.3f,
-recv rate(c/a): %s, %s, bandwidth(c/a): %.3f, %.3f,
-cpu: %.3f%%, data sent: %d, recv: %d`,
-        rate(totalDataSent-r.TotalDataSent, ttd), rate(totalDataSent, tst),
-        float64(totalBytesSent-r.totalBytesSent+1)/float64(totalDataSent-r.TotalDataSent+1),
-        float64(totalBytesSent+1)/float64(totalDataSent+1),
-        rate(totalDataRecv-r.totalDataRecv, ttd), rate(totalDataRecv, tst),
-        float64(totalBytesRecv-r.totalBytesRecv+1)/float64(totalDataRecv-r.totalDataRecv+1),
-        float64(totalBytesRecv+1)/float64(totalDataRecv+1),
-        p,
-        totalDataSent,
-        totalDataRecv,
-    )
-    r.sbandwidth = r.sbandwidth.Next()
-    r.sbandwidth.Value = float64(totalBytesSent-r.totalBytesSent+1) / float64(totalDataSent-r.TotalDataSent+1)
-    r.srates = r.srates.Next()
-    r.srates.Value = float64(totalDataSent-r.TotalDataSent) / float64(ttd)
-    r.rbandwidth = r.rbandwidth.Next()
-    r.rbandwidth.Value = float64(totalBytesRecv-r.totalBytesRecv+1) / float64(totalDataRecv-r.totalDataRecv+1)
-    r.rrates = r.rrates.Next()
-    r.rrates.Value = float64(totalDataRecv-r.totalDataRecv) / float64(ttd)
-    r.cpus = r.cpus.Next()
-    r.cpus.Value = p
-    r.totalBytesSent = totalBytesSent
-    r.totalBytesRecv = totalBytesRecv
-    r.TotalDataSent = totalDataSent
-    r.totalDataRecv = totalDataRecv
+    r.history = r.history.Next()
+    r.history.Value = s
+    r.totalBytesSent, r.TotalDataSent = bytesSent, dataSent
+    r.totalBytesRecv, r.totalDataRecv = bytesRecv, dataRecv
+    return s
+}
+
+// Last returns the newest Snapshot, false before the first tick.
+func (r *RMet) Last() (Snapshot, bool) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    s, ok := r.history.Value.(Snapshot)
+    return s, ok
+}
+
+// History returns the snapshots kept, oldest first.
+func (r *RMet) History() []Snapshot {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    out := make([]Snapshot, 0, r.history.Len())
+    r.history.Next().Do(func(x any) {
+        if s, ok := x.(Snapshot); ok {
+            out = append(out, s)
+        }
+    })
     return out
 }
 
-func rate(n int64, d int64) string {
-    if n > 3*1024*1024 {
-        return fmt.Sprintf("%.3fMB/s", float64(n/1024)/float64(d))
-    }
-    return fmt.Sprintf("%.3fKB/s", float64(n)/float64(d))
+// Summary returns min, max, average and percentiles of the history.
+func (r *RMet) Summary() Summary {
+    return summarize(r.History())
+}
+
+// rate formats n bytes over d with SI units
+func rate(n int64, d time.Duration) string {
+    return convert.FormatRateSI(n, d)
 }
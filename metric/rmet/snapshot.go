@@ -0,0 +1,177 @@
+package rmet
+
+import (
+    "fmt"
+    "math"
+    "sort"
+    "time"
+)
+
+// Snapshot is the state of an RMet at one tick.
+type Snapshot struct {
+    Time     time.Time     `json:"time"`
+    Start    time.Time     `json:"start"`    // when the RMet was created
+    Interval time.Duration `json:"interval"` // since the previous tick, in ns
+    Sent     Flow          `json:"sent"`
+    Recv     Flow          `json:"recv"`
+    CPU      float64       `json:"cpu"` // process CPU percent
+}
+
+// Flow is one direction of a Snapshot. Bytes are counted on the wire
+// (AddBytesSent/AddBytesRecv), Data is the payload (AddDataSent/AddDataRecv).
+type Flow struct {
+    Bytes      int64 `json:"bytes"`
+    Data       int64 `json:"data"`
+    DeltaBytes int64 `json:"delta_bytes"` // since the previous tick
+    DeltaData  int64 `json:"delta_data"`
+    // payload bytes per second since the previous tick and since start
+    Rate    float64 `json:"rate"`
+    AvgRate float64 `json:"avg_rate"`
+    // wire bytes per payload byte since the previous tick and since start,
+    // both counts plus one so idle flows have a ratio of 1
+    Ratio    float64 `json:"ratio"`
+    AvgRatio float64 `json:"avg_ratio"`
+    // failed writes or reads so far
+    Errors int64 `json:"errors"`
+
+    // packets of an MUDPConn, sizes in wire bytes and, for sent packets,
+    // the Write call latency in seconds
+    Packets      int64              `json:"packets,omitempty"`
+    DeltaPackets int64              `json:"delta_packets,omitempty"`
+    PacketRate   float64            `json:"packet_rate,omitempty"`
+    Sizes        *HistogramSnapshot `json:"sizes,omitempty"`
+    Latency      *HistogramSnapshot `json:"latency,omitempty"`
+}
+
+func newFlow(bytes, data, prevBytes, prevData int64, interval, elapsed time.Duration) Flow {
+    return Flow{
+        Bytes:      bytes,
+        Data:       data,
+        DeltaBytes: bytes - prevBytes,
+        DeltaData:  data - prevData,
+        Rate:       float64(data-prevData) / interval.Seconds(),
+        AvgRate:    float64(data) / elapsed.Seconds(),
+        Ratio:      float64(bytes-prevBytes+1) / float64(data-prevData+1),
+        AvgRatio:   float64(bytes+1) / float64(data+1),
+    }
+}
+
+func (f *Flow) setPackets(packets, prev int64, interval time.Duration, sizes, latency *Histogram) {
+    f.Packets = packets
+    f.DeltaPackets = packets - prev
+    f.PacketRate = float64(packets-prev) / interval.Seconds()
+    ss := sizes.Snapshot()
+    f.Sizes = &ss
+    if latency != nil {
+        ls := latency.Snapshot()
+        f.Latency = &ls
+    }
+}
+
+// String formats s the way Tick does.
+func (s Snapshot) String() string {
+    interval := max(s.Interval, time.Millisecond)
+    elapsed := max(s.Time.Sub(s.Start), time.Millisecond)
+    return fmt.Sprintf(`sent rate(c/a): %s, %s, bandwidth(c/a): %.3f, %.3f,
+recv rate(c/a): %s, %s, bandwidth(c/a): %.3f, %.3f,
+cpu: %.3f%%, data sent: %d, recv: %d`,
+        rate(s.Sent.DeltaData, interval), rate(s.Sent.Data, elapsed),
+        s.Sent.Ratio, s.Sent.AvgRatio,
+        rate(s.Recv.DeltaData, interval), rate(s.Recv.Data, elapsed),
+        s.Recv.Ratio, s.Recv.AvgRatio,
+        s.CPU,
+        s.Sent.Data,
+        s.Recv.Data,
+    )
+}
+
+// Stats summarizes a series of values.
+type Stats struct {
+    Count int     `json:"count"`
+    Min   float64 `json:"min"`
+    Max   float64 `json:"max"`
+    Avg   float64 `json:"avg"`
+    P50   float64 `json:"p50"`
+    P90   float64 `json:"p90"`
+    P99   float64 `json:"p99"`
+}
+
+// NewStats summarizes values, the zero Stats if there are none.
+func NewStats(values []float64) Stats {
+    if len(values) == 0 {
+        return Stats{}
+    }
+    sorted := append([]float64(nil), values...)
+    sort.Float64s(sorted)
+    sum := 0.0
+    for _, v := range sorted {
+        sum += v
+    }
+    return Stats{
+        Count: len(sorted),
+        Min:   sorted[0],
+        Max:   sorted[len(sorted)-1],
+        Avg:   sum / float64(len(sorted)),
+        P50:   percentile(sorted, 50),
+        P90:   percentile(sorted, 90),
+        P99:   percentile(sorted, 99),
+    }
+}
+
+// Percentile returns the p-th percentile, 0 to 100, of values,
+// interpolating linearly between the closest ranks.
+func Percentile(values []float64, p float64) float64 {
+    if len(values) == 0 {
+        return 0
+    }
+    sorted := append([]float64(nil), values...)
+    sort.Float64s(sorted)
+    return percentile(sorted, p)
+}
+
+func percentile(sorted []float64, p float64) float64 {
+    p = math.Max(0, math.Min(100, p))
+    pos := p / 100 * float64(len(sorted)-1)
+    i := int(pos)
+    if i+1 >= len(sorted) {
+        return sorted[len(sorted)-1]
+    }
+    return sorted[i] + (sorted[i+1]-sorted[i])*(pos-float64(i))
+}
+
+// Summary summarizes the history of an RMet.
+type Summary struct {
+    SendRate  Stats `json:"send_rate"`
+    RecvRate  Stats `json:"recv_rate"`
+    SendRatio Stats `json:"send_ratio"`
+    RecvRatio Stats `json:"recv_ratio"`
+    CPU       Stats `json:"cpu"`
+    // packets per second, MUDPConn only
+    SendPacketRate Stats `json:"send_packet_rate"`
+    RecvPacketRate Stats `json:"recv_packet_rate"`
+}
+
+func summarize(history []Snapshot) Summary {
+    series := make([][]float64, 7)
+    for _, s := range history {
+        series[0] = append(series[0], s.Sent.Rate)
+        series[1] = append(series[1], s.Recv.Rate)
+        series[2] = append(series[2], s.Sent.Ratio)
+        series[3] = append(series[3], s.Recv.Ratio)
+        series[4] = append(series[4], s.CPU)
+        if s.Sent.Sizes != nil {
+            series[5] = append(series[5], s.Sent.PacketRate)
+            series[6] = append(series[6], s.Recv.PacketRate)
+        }
+    }
+    return Summary{
+        SendRate:  NewStats(series[0]),
+        RecvRate:  NewStats(series[1]),
+        SendRatio: NewStats(series[2]),
+        RecvRatio: NewStats(series[3]),
+        CPU:       NewStats(series[4]),
+
+        SendPacketRate: NewStats(series[5]),
+        RecvPacketRate: NewStats(series[6]),
+    }
+}
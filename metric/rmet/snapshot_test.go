@@ -0,0 +1,126 @@
+package rmet_test
+
+import (
+    "encoding/json"
+    "math"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/kakami/pkg/metric/rmet"
+)
+
+func Test_Snapshot(t *testing.T) {
+    r := rmet.New(10)
+    if _, ok := r.Last(); ok {
+        t.Fatal("snapshot before the first tick")
+    }
+
+    r.AddBytesSent(150)
+    r.AddDataSent(100)
+    r.AddDataRecv(10)
+    time.Sleep(10 * time.Millisecond)
+    s := r.TickSnapshot()
+    if s.Sent.Bytes != 150 || s.Sent.Data != 100 || s.Sent.DeltaData != 100 || s.Recv.Data != 10 {
+        t.Fatalf("first %+v", s)
+    }
+    if s.Sent.Rate <= 0 || s.Sent.Rate > 100/0.01 || s.Interval < 10*time.Millisecond {
+        t.Fatalf("rate %v over %v", s.Sent.Rate, s.Interval)
+    }
+    if s.Sent.Ratio != 151.0/101 || s.Recv.Ratio != 1.0/11 {
+        t.Fatalf("ratios %v %v", s.Sent.Ratio, s.Recv.Ratio)
+    }
+    if !s.Start.Before(s.Time) {
+        t.Fatalf("start %v time %v", s.Start, s.Time)
+    }
+
+    r.AddDataSent(50)
+    s2 := r.TickSnapshot()
+    if s2.Sent.Data != 150 || s2.Sent.DeltaData != 50 || s2.Sent.DeltaBytes != 0 {
+        t.Fatalf("second %+v", s2)
+    }
+    if last, ok := r.Last(); !ok || last.Time != s2.Time {
+        t.Fatalf("last %+v", last)
+    }
+    if !strings.HasPrefix(s2.String(), "sent rate(c/a): ") || !strings.HasSuffix(s2.String(), "data sent: 150, recv: 10") {
+        t.Fatalf("string %q", s2.String())
+    }
+
+    data, err := json.Marshal(s2)
+    if err != nil {
+        t.Fatal(err)
+    }
+    var back rmet.Snapshot
+    if err := json.Unmarshal(data, &back); err != nil || back.Sent != s2.Sent || !back.Time.Equal(s2.Time) {
+        t.Fatalf("round trip %s: %+v %v", data, back, err)
+    }
+    if !strings.Contains(string(data), `"delta_data":50`) {
+        t.Fatalf("json %s", data)
+    }
+
+    for i := 0; i < 12; i++ {
+        r.TickSnapshot()
+    }
+    h := r.History()
+    if len(h) != 10 || !h[0].Time.Before(h[9].Time) {
+        t.Fatalf("history of %d", len(h))
+    }
+    sum := r.Summary()
+    if sum.SendRate.Count != 10 || sum.SendRate.Min != 0 || sum.SendRatio.Max != 1 {
+        t.Fatalf("summary %+v", sum)
+    }
+    srates, _, _, _, cpus := r.Metrics()
+    if len(srates) != 10 || len(cpus) != 10 {
+        t.Fatalf("metrics %d %d", len(srates), len(cpus))
+    }
+}
+
+func Test_SnapshotString(t *testing.T) {
+    start := time.Unix(1700000000, 0)
+    s := rmet.Snapshot{
+        Start:    start,
+        Time:     start.Add(2 * time.Second),
+        Interval: time.Second,
+        Sent:     rmet.Flow{Data: 3e6, DeltaData: 1500, Ratio: 1, AvgRatio: 1.5},
+        Recv:     rmet.Flow{Data: 100, DeltaData: 100},
+        CPU:      12.5,
+    }
+    want := `sent rate(c/a): 1.5kB/s, 1.5MB/s, bandwidth(c/a): 1.000, 1.500,
+recv rate(c/a): 100B/s, 50B/s, bandwidth(c/a): 0.000, 0.000,
+cpu: 12.500%, data sent: 3000000, recv: 100`
+    if got := s.String(); got != want {
+        t.Fatalf("got %q", got)
+    }
+}
+
+func Test_Tick(t *testing.T) {
+    r := rmet.New(10)
+    r.AddDataSent(5e6)
+    out := r.Tick()
+    last, ok := r.Last()
+    if !ok || out != last.String() {
+        t.Fatalf("tick %q, snapshot %q", out, last.String())
+    }
+    if !strings.Contains(out, "B/s") || strings.Contains(out, "iB/s") {
+        t.Fatalf("tick %q", out)
+    }
+}
+
+func Test_Stats(t *testing.T) {
+    s := rmet.NewStats([]float64{5, 1, 4, 2, 3})
+    if s.Count != 5 || s.Min != 1 || s.Max != 5 || s.Avg != 3 || s.P50 != 3 {
+        t.Fatalf("%+v", s)
+    }
+    if math.Abs(s.P90-4.6) > 1e-9 || math.Abs(s.P99-4.96) > 1e-9 {
+        t.Fatalf("%+v", s)
+    }
+    if p := rmet.Percentile([]float64{10, 20}, 25); p != 12.5 {
+        t.Fatalf("p25 %v", p)
+    }
+    if p := rmet.Percentile(nil, 50); p != 0 {
+        t.Fatalf("empty %v", p)
+    }
+    if s := rmet.NewStats(nil); s != (rmet.Stats{}) {
+        t.Fatalf("empty %+v", s)
+    }
+}
@@ -0,0 +1,123 @@
+package rmet_test
+
+import (
+    "math"
+    "net"
+    "net/http/httptest"
+    "testing"
+
+    dto "github.com/prometheus/client_model/go"
+    "github.com/prometheus/common/expfmt"
+
+    "github.com/kakami/pkg/metric/rmet"
+)
+
+func listenUDP(t *testing.T) *net.UDPConn {
+    t.Helper()
+    conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+    if err != nil {
+        t.Fatal(err)
+    }
+    return conn
+}
+
+func Test_MUDPConnPackets(t *testing.T) {
+    reg := rmet.NewRegistry()
+    rtp := func(b []byte) int { return max(len(b)-12, 0) }
+    buckets := []float64{128, 1500}
+    a := reg.NewUDPConn(listenUDP(t), 10, "relay", rmet.Labels{"side": "a"},
+        rmet.WithSizeBuckets(buckets), rmet.WithPayloadSize(rtp), rmet.WithLatencyBuckets([]float64{1e-3, 1}))
+    defer a.Close()
+    b := rmet.NewUDPConn(listenUDP(t), 10, rmet.WithSizeBuckets(buckets), rmet.WithPayloadSize(rtp))
+    defer b.Close()
+
+    sizes := []int{100, 200, 2000}
+    for _, n := range sizes {
+        if _, err := a.WriteToUDP(make([]byte, n), b.LocalAddr().(*net.UDPAddr)); err != nil {
+            t.Fatal(err)
+        }
+    }
+    buf := make([]byte, 4096)
+    for range sizes {
+        if _, _, err := b.ReadFromUDP(buf); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    sa, sb := a.TickSnapshot(), b.TickSnapshot()
+    if sa.Sent.Packets != 3 || sa.Sent.DeltaPackets != 3 || sa.Sent.PacketRate <= 0 {
+        t.Fatalf("sent %+v", sa.Sent)
+    }
+    if sa.Sent.Bytes != 2300 || sa.Sent.Data != 2300-36 {
+        t.Fatalf("sent bytes %d data %d", sa.Sent.Bytes, sa.Sent.Data)
+    }
+    if got := sa.Sent.Sizes.Counts; len(got) != 3 || got[0] != 1 || got[1] != 1 || got[2] != 1 {
+        t.Fatalf("sent sizes %+v", sa.Sent.Sizes)
+    }
+    if sa.Sent.Latency.Count != 3 || sa.Recv.Packets != 0 || sa.Recv.Latency != nil {
+        t.Fatalf("latency %+v recv %+v", sa.Sent.Latency, sa.Recv)
+    }
+    if sb.Recv.Packets != 3 || sb.Recv.Data != 2300-36 || sb.Recv.Sizes.Sum != 2300 {
+        t.Fatalf("recv %+v", sb.Recv)
+    }
+    if len(sa.Sent.Latency.Bounds) != 2 || sb.Recv.Latency != nil {
+        t.Fatalf("latency %+v %+v", sa.Sent.Latency, sb.Recv.Latency)
+    }
+    if sum := b.Summary(); sum.RecvPacketRate.Count != 1 || sum.RecvPacketRate.Max <= 0 {
+        t.Fatalf("summary %+v", sum.RecvPacketRate)
+    }
+
+    srv := httptest.NewServer(reg.Handler())
+    defer srv.Close()
+    resp, err := srv.Client().Get(srv.URL)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer resp.Body.Close()
+    var parser expfmt.TextParser
+    families, err := parser.TextToMetricFamilies(resp.Body)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if v := families["rmet_sent_packets_total"].GetMetric()[0].GetCounter().GetValue(); v != 3 {
+        t.Fatalf("packets %v", v)
+    }
+    f := families["rmet_sent_packet_size_bytes"]
+    if f.GetType() != dto.MetricType_HISTOGRAM {
+        t.Fatalf("type %v", f.GetType())
+    }
+    h := f.GetMetric()[0].GetHistogram()
+    if h.GetSampleCount() != 3 || h.GetSampleSum() != 2300 || len(h.GetBucket()) != 3 {
+        t.Fatalf("histogram %v", h)
+    }
+    if h.GetBucket()[0].GetCumulativeCount() != 1 || h.GetBucket()[1].GetCumulativeCount() != 2 {
+        t.Fatalf("buckets %v", h.GetBucket())
+    }
+    if families["rmet_write_duration_seconds"] == nil || families["rmet_read_duration_seconds"] != nil {
+        t.Fatal("latency families")
+    }
+}
+
+func Test_Histogram(t *testing.T) {
+    h := rmet.NewHistogram([]float64{10, 1, 100})
+    for _, v := range []float64{0.5, 1, 5, 5, 50, 500} {
+        h.Observe(v)
+    }
+    s := h.Snapshot()
+    if s.Count != 6 || s.Sum != 561.5 || s.Bounds[0] != 1 {
+        t.Fatalf("%+v", s)
+    }
+    if want := []int64{2, 2, 1, 1}; len(s.Counts) != 4 || s.Counts[0] != want[0] || s.Counts[1] != want[1] ||
+        s.Counts[2] != want[2] || s.Counts[3] != want[3] {
+        t.Fatalf("counts %v", s.Counts)
+    }
+    if q := s.Quantile(0.5); q != 5.5 {
+        t.Fatalf("median %v", q)
+    }
+    if q := s.Quantile(1); q != 100 {
+        t.Fatalf("max %v", q)
+    }
+    if m := s.Mean(); math.Abs(m-561.5/6) > 1e-9 {
+        t.Fatalf("mean %v", m)
+    }
+}
@@ -0,0 +1,266 @@
+package rmet
+
+import (
+    "container/ring"
+    "context"
+    "errors"
+    "net"
+    "net/http"
+    "sync"
+    "time"
+
+    "go.uber.org/atomic"
+)
+
+// ConnCounter counts the conns a Listener accepts or a Dialer dials.
+type ConnCounter struct {
+    opened *atomic.Int64
+    failed *atomic.Int64
+    active *atomic.Int64
+    closed *atomic.Int64
+
+    mu        sync.Mutex
+    durations *ring.Ring // of float64 seconds, at the newest
+}
+
+// ConnStats is the state of a ConnCounter.
+type ConnStats struct {
+    Opened int64 `json:"opened"` // accepted or dialed
+    Failed int64 `json:"failed"` // Accept or dial errors, not a closed listener
+    Active int64 `json:"active"`
+    Closed int64 `json:"closed"`
+    // seconds the most recently closed conns were open
+    Duration Stats `json:"duration"`
+}
+
+// NewConnCounter keeps the durations of the last size closed conns.
+func NewConnCounter(size int) *ConnCounter {
+    return &ConnCounter{
+        opened:    atomic.NewInt64(0),
+        failed:    atomic.NewInt64(0),
+        active:    atomic.NewInt64(0),
+        closed:    atomic.NewInt64(0),
+        durations: ring.New(max(size, 1)),
+    }
+}
+
+func (c *ConnCounter) Stats() ConnStats {
+    c.mu.Lock()
+    var durations []float64
+    c.durations.Do(func(x any) {
+        if d, ok := x.(float64); ok {
+            durations = append(durations, d)
+        }
+    })
+    c.mu.Unlock()
+    return ConnStats{
+        Opened:   c.opened.Load(),
+        Failed:   c.failed.Load(),
+        Active:   c.active.Load(),
+        Closed:   c.closed.Load(),
+        Duration: NewStats(durations),
+    }
+}
+
+// track wraps conn as an MConn counted by c.
+func (c *ConnCounter) track(conn net.Conn, o *options) *MConn {
+    c.opened.Inc()
+    c.active.Inc()
+    var mc *MConn
+    if o.reg != nil {
+        labels := o.labels
+        if o.connLabels != nil {
+            labels = make(Labels)
+            for k, v := range o.labels {
+                labels[k] = v
+            }
+            for k, v := range o.connLabels(conn) {
+                labels[k] = v
+            }
+        }
+        mc = o.reg.NewConn(conn, o.size, o.name, labels)
+    } else {
+        mc = NewConn(conn, o.size)
+    }
+    start := time.Now()
+    mc.onClose = func() {
+        c.active.Dec()
+        c.closed.Inc()
+        c.mu.Lock()
+        c.durations = c.durations.Next()
+        c.durations.Value = time.Since(start).Seconds()
+        c.mu.Unlock()
+    }
+    return mc
+}
+
+// Option configures WrapListener, NewDialer, Transport and NewUDPConn.
+type Option func(*options)
+
+type options struct {
+    size       int
+    counter    *ConnCounter
+    reg        *Registry
+    name       string
+    labels     Labels
+    connLabels func(net.Conn) Labels
+
+    sizeBuckets    []float64
+    latencyBuckets []float64
+    payloadSize    func([]byte) int
+}
+
+func newOptions(opts []Option) *options {
+    o := &options{
+        size:           10,
+        sizeBuckets:    DefaultSizeBuckets,
+        latencyBuckets: DefaultLatencyBuckets,
+    }
+    for _, opt := range opts {
+        opt(o)
+    }
+    return o
+}
+
+// WithHistory sets the history size of every conn's RMet, default 10.
+func WithHistory(n int) Option {
+    return func(o *options) {
+        o.size = n
+    }
+}
+
+// WithConnCounter counts into c, e.g. to share it between listeners.
+func WithConnCounter(c *ConnCounter) Option {
+    return func(o *options) {
+        o.counter = c
+    }
+}
+
+// WithRegistry registers every conn in g under name and labels until it
+// is closed.
+func WithRegistry(g *Registry, name string, labels Labels) Option {
+    return func(o *options) {
+        o.reg = g
+        o.name = name
+        o.labels = labels
+    }
+}
+
+// WithSizeBuckets sets the packet size histogram bounds of an MUDPConn,
+// DefaultSizeBuckets by default.
+func WithSizeBuckets(bounds []float64) Option {
+    return func(o *options) {
+        o.sizeBuckets = bounds
+    }
+}
+
+// WithLatencyBuckets sets the Write latency histogram bounds of an
+// MUDPConn, DefaultLatencyBuckets by default. Reads are not timed, a
+// blocking Read mostly waits for the next packet.
+func WithLatencyBuckets(bounds []float64) Option {
+    return func(o *options) {
+        o.latencyBuckets = bounds
+    }
+}
+
+// WithPayloadSize makes an MUDPConn count fn(packet) payload bytes for
+// every packet read or written, e.g. the packet minus its RTP header, on
+// top of the wire bytes counted as usual.
+func WithPayloadSize(fn func(packet []byte) int) Option {
+    return func(o *options) {
+        o.payloadSize = fn
+    }
+}
+
+// WithConnLabels adds labels computed per conn to those of WithRegistry,
+// e.g. the remote address. Mind the number of distinct values.
+func WithConnLabels(fn func(net.Conn) Labels) Option {
+    return func(o *options) {
+        o.connLabels = fn
+    }
+}
+
+// Listener meters every conn it accepts, they are returned as *MConn.
+type Listener struct {
+    net.Listener
+    *ConnCounter
+    o *options
+}
+
+// WrapListener meters the conns accepted from l.
+func WrapListener(l net.Listener, opts ...Option) *Listener {
+    o := newOptions(opts)
+    if o.counter == nil {
+        o.counter = NewConnCounter(100)
+    }
+    return &Listener{
+        Listener:    l,
+        ConnCounter: o.counter,
+        o:           o,
+    }
+}
+
+func (l *Listener) Accept() (net.Conn, error) {
+    conn, err := l.Listener.Accept()
+    if err != nil {
+        if !errors.Is(err, net.ErrClosed) {
+            l.failed.Inc()
+        }
+        return nil, err
+    }
+    return l.track(conn, l.o), nil
+}
+
+// DialFunc is the signature of net.Dialer.DialContext.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// Dialer meters every conn it dials, they are returned as *MConn.
+type Dialer struct {
+    *ConnCounter
+    dial DialFunc
+    o    *options
+}
+
+// NewDialer meters the conns dialed by dial, a net.Dialer if nil.
+func NewDialer(dial DialFunc, opts ...Option) *Dialer {
+    if dial == nil {
+        dial = (&net.Dialer{}).DialContext
+    }
+    o := newOptions(opts)
+    if o.counter == nil {
+        o.counter = NewConnCounter(100)
+    }
+    return &Dialer{
+        ConnCounter: o.counter,
+        dial:        dial,
+        o:           o,
+    }
+}
+
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+    conn, err := d.dial(ctx, network, address)
+    if err != nil {
+        d.failed.Inc()
+        return nil, err
+    }
+    return d.track(conn, d.o), nil
+}
+
+// Transport returns a clone of t, http.DefaultTransport if nil, whose
+// conns are dialed by the returned Dialer. A DialTLSContext set on t is
+// not metered.
+func Transport(t *http.Transport, opts ...Option) (*http.Transport, *Dialer) {
+    if t == nil {
+        t = http.DefaultTransport.(*http.Transport)
+    }
+    t = t.Clone()
+    var dial DialFunc
+    if t.DialContext != nil {
+        dial = t.DialContext
+    } else {
+        dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+    }
+    d := NewDialer(dial, opts...)
+    t.DialContext = d.DialContext
+    return t, d
+}
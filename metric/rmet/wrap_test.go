@@ -0,0 +1,123 @@
+package rmet_test
+
+import (
+    "context"
+    "errors"
+    "io"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/kakami/pkg/metric/rmet"
+)
+
+func Test_WrapListenerAndTransport(t *testing.T) {
+    reg := rmet.NewRegistry()
+    srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+        w.Write([]byte("hello"))
+    }))
+    ln := rmet.WrapListener(srv.Listener, rmet.WithRegistry(reg, "server", nil))
+    srv.Listener = ln
+    srv.Start()
+    defer srv.Close()
+
+    tr, dialer := rmet.Transport(nil, rmet.WithRegistry(reg, "client", rmet.Labels{"svc": "test"}),
+        rmet.WithConnLabels(func(c net.Conn) rmet.Labels {
+            return rmet.Labels{"remote": c.RemoteAddr().String()}
+        }))
+    client := &http.Client{Transport: tr}
+    for i := 0; i < 2; i++ {
+        resp, err := client.Get(srv.URL)
+        if err != nil {
+            t.Fatal(err)
+        }
+        io.Copy(io.Discard, resp.Body)
+        resp.Body.Close()
+    }
+
+    if s := dialer.Stats(); s.Opened != 1 || s.Active != 1 || s.Failed != 0 {
+        t.Fatalf("dialer %+v", s)
+    }
+    if s := ln.Stats(); s.Opened != 1 || s.Active != 1 {
+        t.Fatalf("listener %+v", s)
+    }
+    groups := reg.Aggregate("svc", "remote")
+    if len(groups) != 2 || groups[0].Name != "client" || groups[0].Labels["remote"] != srv.Listener.Addr().String() {
+        t.Fatalf("groups %+v", groups)
+    }
+    if groups[0].BytesSent == 0 || groups[0].BytesRecv == 0 || groups[1].BytesRecv != groups[0].BytesSent {
+        t.Fatalf("groups %+v", groups)
+    }
+
+    tr.CloseIdleConnections()
+    deadline := time.Now().Add(time.Second)
+    for ln.Stats().Active != 0 && time.Now().Before(deadline) {
+        time.Sleep(5 * time.Millisecond)
+    }
+    s := dialer.Stats()
+    if s.Active != 0 || s.Closed != 1 || s.Duration.Count != 1 || s.Duration.Max <= 0 {
+        t.Fatalf("dialer after close %+v", s)
+    }
+    if s := ln.Stats(); s.Active != 0 || s.Closed != 1 {
+        t.Fatalf("listener after close %+v", s)
+    }
+    if reg.Len() != 0 {
+        t.Fatalf("registered %d", reg.Len())
+    }
+}
+
+func Test_DialerErrors(t *testing.T) {
+    fail := errors.New("refused")
+    d := rmet.NewDialer(func(context.Context, string, string) (net.Conn, error) {
+        return nil, fail
+    })
+    if _, err := d.DialContext(context.Background(), "tcp", "x:1"); err != fail {
+        t.Fatal(err)
+    }
+    if s := d.Stats(); s.Failed != 1 || s.Opened != 0 {
+        t.Fatalf("%+v", s)
+    }
+
+    c1, c2 := net.Pipe()
+    d = rmet.NewDialer(func(context.Context, string, string) (net.Conn, error) {
+        return c1, nil
+    })
+    conn, err := d.DialContext(context.Background(), "pipe", "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    mc := conn.(*rmet.MConn)
+    c2.Close()
+    if _, err := mc.Write([]byte("x")); err == nil {
+        t.Fatal("write to closed pipe")
+    }
+    if _, err := mc.Read(make([]byte, 1)); err != io.EOF {
+        t.Fatal(err)
+    }
+    s := mc.TickSnapshot()
+    if s.Sent.Errors != 1 || s.Recv.Errors != 0 {
+        t.Fatalf("errors %+v %+v", s.Sent, s.Recv)
+    }
+    mc.Close()
+    mc.Close()
+    if s := d.Stats(); s.Closed != 1 || s.Active != 0 {
+        t.Fatalf("%+v", s)
+    }
+}
+
+func Test_ListenerClose(t *testing.T) {
+    l, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    ln := rmet.WrapListener(l)
+    ln.Close()
+    if _, err := ln.Accept(); !errors.Is(err, net.ErrClosed) {
+        t.Fatal(err)
+    }
+    if s := ln.Stats(); s.Failed != 0 {
+        t.Fatalf("closed listener counted as failed %+v", s)
+    }
+}
@@ -0,0 +1,83 @@
+package zlog
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// TailWriter passes writes through to w and remembers the last n lines,
+// e.g. to attach recent logs to a crash report:
+//
+//	tail := zlog.NewTailWriter(os.Stdout, 200)
+//	zlog.SetWriter(tail)
+type TailWriter struct {
+	w io.Writer
+
+	mu    sync.Mutex
+	lines []string
+	next  int
+	full  bool
+	part  []byte // unterminated last line
+}
+
+// NewTailWriter returns a TailWriter keeping n lines, w may be nil.
+func NewTailWriter(w io.Writer, n int) *TailWriter {
+	if n <= 0 {
+		n = 1
+	}
+	return &TailWriter{
+		w:     w,
+		lines: make([]string, n),
+	}
+}
+
+func (t *TailWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	data := p
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			t.part = append(t.part, data...)
+			break
+		}
+		t.push(string(append(t.part, data[:i]...)))
+		t.part = t.part[:0]
+		data = data[i+1:]
+	}
+	t.mu.Unlock()
+
+	if t.w == nil {
+		return len(p), nil
+	}
+	return t.w.Write(p)
+}
+
+func (t *TailWriter) push(line string) {
+	t.lines[t.next] = line
+	t.next++
+	if t.next == len(t.lines) {
+		t.next = 0
+		t.full = true
+	}
+}
+
+// Lines returns the remembered lines, oldest first, without line endings.
+// An unterminated last line is included.
+func (t *TailWriter) Lines() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var out []string
+	if t.full {
+		out = append(out, t.lines[t.next:]...)
+	}
+	out = append(out, t.lines[:t.next]...)
+	if len(t.part) > 0 {
+		if len(out) == len(t.lines) {
+			out = out[1:]
+		}
+		out = append(out, string(t.part))
+	}
+	return out
+}
@@ -0,0 +1,37 @@
+package zlog_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/kakami/pkg/zlog"
+)
+
+func Test_TailWriter(t *testing.T) {
+	var buf bytes.Buffer
+	tail := zlog.NewTailWriter(&buf, 3)
+	tail.Write([]byte("a\nb\n"))
+	if got := tail.Lines(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("got %q", got)
+	}
+
+	tail.Write([]byte("c\nd\ne"))
+	if got := tail.Lines(); !reflect.DeepEqual(got, []string{"c", "d", "e"}) {
+		t.Fatalf("got %q", got)
+	}
+	tail.Write([]byte("f\n"))
+	if got := tail.Lines(); !reflect.DeepEqual(got, []string{"c", "d", "ef"}) {
+		t.Fatalf("got %q", got)
+	}
+	if buf.String() != "a\nb\nc\nd\nef\n" {
+		t.Fatalf("passed through %q", buf.String())
+	}
+
+	log := zlog.DefaultLogger(tail)
+	log.Info("hello")
+	lines := tail.Lines()
+	if !bytes.Contains([]byte(lines[len(lines)-1]), []byte("hello")) {
+		t.Fatalf("got %q", lines)
+	}
+}